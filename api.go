@@ -1,9 +1,10 @@
 package pricing
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
-	"fmt"
+	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
@@ -24,165 +25,779 @@ type (
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	}
+	RenameBrandRequest struct {
+		Name string `json:"name"`
+	}
+	RenameBrandResponse struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
 	AddPriceRequest struct {
 		BrandID   int       `json:"brand_id"`
 		StartDate time.Time `json:"start_date"`
 		EndDate   time.Time `json:"end_date"`
 		ProductID int       `json:"product_id"`
 		Priority  int       `json:"priority"`
-		Price     int       `json:"price"`
-		Curr      string    `json:"curr"`
+		Price     Money     `json:"price"`
+	}
+	AddPriceResponse struct {
+		ID        int       `json:"id"`
+		BrandID   int       `json:"brand_id"`
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		ProductID int       `json:"product_id"`
+		Priority  int       `json:"priority"`
+		Price     Money     `json:"price"`
+	}
+	GetPriceByIDResponse struct {
+		ID        int       `json:"id"`
+		BrandID   int       `json:"brand_id"`
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		ProductID int       `json:"product_id"`
+		Priority  int       `json:"priority"`
+		Price     Money     `json:"price"`
+	}
+	// UpdatePriceRequest replaces every field of a price.
+	UpdatePriceRequest struct {
+		BrandID   int       `json:"brand_id"`
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		ProductID int       `json:"product_id"`
+		Priority  int       `json:"priority"`
+		Price     Money     `json:"price"`
+	}
+	UpdatePriceResponse struct {
+		ID        int       `json:"id"`
+		BrandID   int       `json:"brand_id"`
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		ProductID int       `json:"product_id"`
+		Priority  int       `json:"priority"`
+		Price     Money     `json:"price"`
+	}
+	// PatchPriceRequest replaces only the provided fields of a price.
+	PatchPriceRequest struct {
+		BrandID   *int       `json:"brand_id"`
+		StartDate *time.Time `json:"start_date"`
+		EndDate   *time.Time `json:"end_date"`
+		ProductID *int       `json:"product_id"`
+		Priority  *int       `json:"priority"`
+		Price     *Money     `json:"price"`
 	}
 	GetPriceRequest struct {
 		BrandID   int       `json:"brand_id"`
 		ProductID int       `json:"product_id"`
 		Date      time.Time `json:"date"`
 		StringID  string    `json:"string_id"`
+		// LowestPriorPrice requests GetPriceResponse.LowestPriorPrice, it
+		// costs another repository query so it's omitted by default.
+		LowestPriorPrice bool `json:"lowest_prior_price"`
 	}
 	GetPriceResponse struct {
-		BrandID   int    `json:"brand_id"`
-		ProductID int    `json:"product_id"`
-		Price     string `json:"price"`
-		Curr      string `json:"curr"`
-		StartDate string `json:"start_date"`
-		EndDate   string `json:"end_date"`
-		StringID  string `json:"string_id"`
+		PriceID   int   `json:"price_id"`
+		BrandID   int   `json:"brand_id"`
+		ProductID int   `json:"product_id"`
+		Price     Money `json:"price"`
+		// CompareAtPriceID and CompareAtPrice are the price that would apply
+		// without the override of PriceID, e.g: to show a strikethrough
+		// price, omitted if no other price applies.
+		CompareAtPriceID int    `json:"compare_at_price_id,omitempty"`
+		CompareAtPrice   *Money `json:"compare_at_price,omitempty"`
+		// OriginalPrice is the price before the Discount of the promotion
+		// with PromotionID, which is omitted if no promotion applies.
+		OriginalPrice Money `json:"original_price"`
+		Discount      Money `json:"discount"`
+		PromotionID   int   `json:"promotion_id,omitempty"`
+		// LowestPriorPrice is the lowest price applied during the
+		// LowestPriorPriceWindow before EffectiveStartDate, as required by
+		// the EU Omnibus Directive when advertising a price reduction. Only
+		// returned by GetPrice when requested, omitted if no price in the
+		// same currency applied or the lookup failed.
+		LowestPriorPrice *Money    `json:"lowest_prior_price,omitempty"`
+		StartDate        time.Time `json:"start_date"`
+		EndDate          time.Time `json:"end_date"`
+		// EffectiveStartDate and EffectiveEndDate span how long this price
+		// applies for before another price takes over, or no price applies.
+		EffectiveStartDate time.Time `json:"effective_start_date"`
+		EffectiveEndDate   time.Time `json:"effective_end_date"`
+		StringID           string    `json:"string_id"`
+	}
+
+	BatchCreatePricesRequest struct {
+		Prices []AddPriceRequest `json:"prices"`
+	}
+
+	BatchCreatePricesResponse struct {
+		Prices []AddPriceResponse `json:"prices"`
+	}
+
+	BatchGetPricesRequest struct {
+		BrandID    int       `json:"brand_id"`
+		ProductIDs []int     `json:"product_ids"`
+		Date       time.Time `json:"date"`
+		StringID   string    `json:"string_id"`
+	}
+
+	BatchGetPricesResponse struct {
+		Results  []BatchGetPriceResult `json:"results"`
+		StringID string                `json:"string_id"`
+	}
+
+	// BatchGetPriceResult holds either the Price or the Error of looking up a
+	// single product, in the order of the request's product_ids.
+	BatchGetPriceResult struct {
+		ProductID int               `json:"product_id"`
+		Price     *GetPriceResponse `json:"price,omitempty"`
+		Error     *Problem          `json:"error,omitempty"`
+	}
+
+	// AddPromotionRequest sets one of PercentOff and AmountOff.
+	AddPromotionRequest struct {
+		BrandID    int       `json:"brand_id"`
+		ProductIDs []int     `json:"product_ids"`
+		StartDate  time.Time `json:"start_date"`
+		EndDate    time.Time `json:"end_date"`
+		PercentOff int       `json:"percent_off,omitempty"`
+		AmountOff  *Money    `json:"amount_off,omitempty"`
+	}
+	AddPromotionResponse struct {
+		ID         int       `json:"id"`
+		BrandID    int       `json:"brand_id"`
+		ProductIDs []int     `json:"product_ids"`
+		StartDate  time.Time `json:"start_date"`
+		EndDate    time.Time `json:"end_date"`
+		PercentOff int       `json:"percent_off,omitempty"`
+		AmountOff  *Money    `json:"amount_off,omitempty"`
+	}
+	GetPromotionByIDResponse struct {
+		ID         int       `json:"id"`
+		BrandID    int       `json:"brand_id"`
+		ProductIDs []int     `json:"product_ids"`
+		StartDate  time.Time `json:"start_date"`
+		EndDate    time.Time `json:"end_date"`
+		PercentOff int       `json:"percent_off,omitempty"`
+		AmountOff  *Money    `json:"amount_off,omitempty"`
+	}
+
+	GetPriceTimelineResponse struct {
+		BrandID   int                    `json:"brand_id"`
+		ProductID int                    `json:"product_id"`
+		Segments  []PriceSegmentResponse `json:"segments"`
+	}
+
+	// PriceSegmentResponse omits PriceID and Price where no price applies.
+	PriceSegmentResponse struct {
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		PriceID   int       `json:"price_id,omitempty"`
+		Price     *Money    `json:"price,omitempty"`
 	}
 )
 
 // Handler will expose our service via an "open host service"
 type Handler struct {
-	svc *Service
-	// logger zerolog.Logger // log http queries, etc
+	svc    *Service
+	logger *slog.Logger // access logs, see AccessLog
+}
+
+// HandlerOption configures a Handler created by NewHandler.
+type HandlerOption func(*Handler)
+
+// WithHandlerLogger sets the logger of access logs, defaults to slog.Default.
+func WithHandlerLogger(logger *slog.Logger) HandlerOption {
+	return func(h *Handler) { h.logger = logger }
 }
 
-func NewHandler(svc *Service) (*Handler, error) {
+func NewHandler(svc *Service, opts ...HandlerOption) (*Handler, error) {
 	if svc == nil {
 		return nil, errors.New("service cannot be empty")
 	}
 
-	return &Handler{svc: svc}, nil
+	h := &Handler{svc: svc, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h, nil
+}
+
+// RegisterRoutes registers the API endpoints with mux.
+func (h Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/", h.NotFound)
+	mux.HandleFunc("GET /api/v1/brands", h.GetBrand)
+	mux.HandleFunc("POST /api/v1/brands", h.AddBrand)
+	mux.HandleFunc("PATCH /api/v1/brands/{id}", h.RenameBrand)
+	mux.HandleFunc("DELETE /api/v1/brands/{id}", h.DeleteBrand)
+	mux.HandleFunc("GET /api/v1/prices", h.GetPrice)
+	mux.HandleFunc("POST /api/v1/prices", h.AddPrice)
+	mux.HandleFunc("POST /api/v1/prices:batchCreate", h.BatchCreatePrices)
+	mux.HandleFunc("POST /api/v1/prices:batchGet", h.BatchGetPrices)
+	mux.HandleFunc("GET /api/v1/prices/timeline", h.GetPriceTimeline)
+	mux.HandleFunc("POST /api/v1/prices/import", h.ImportPrices)
+	mux.HandleFunc("GET /api/v1/prices/export", h.ExportPrices)
+	mux.HandleFunc("GET /api/v1/prices/{id}", h.GetPriceByID)
+	mux.HandleFunc("PUT /api/v1/prices/{id}", h.UpdatePrice)
+	mux.HandleFunc("PATCH /api/v1/prices/{id}", h.PatchPrice)
+	mux.HandleFunc("DELETE /api/v1/prices/{id}", h.DeletePrice)
+	mux.HandleFunc("POST /api/v1/promotions", h.AddPromotion)
+	mux.HandleFunc("GET /api/v1/promotions/{id}", h.GetPromotionByID)
+	mux.HandleFunc("DELETE /api/v1/promotions/{id}", h.DeletePromotion)
+}
+
+// NotFound responds to requests for routes that don't exist.
+func (h Handler) NotFound(w http.ResponseWriter, req *http.Request) {
+	writeProblem(w, req, http.StatusNotFound, CodeNotFound, "", "no route matches the request path")
 }
 
 func (h Handler) AddBrand(w http.ResponseWriter, req *http.Request) {
-	// TODO, not implemented.
-	w.WriteHeader(http.StatusNotImplemented)
+	var abr AddBrandRequest
+	if !decodeJSON(w, req, &abr) {
+		return
+	}
+
+	brand, err := h.svc.AddBrand(req.Context(), abr.Name)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusCreated, AddBrandResponse(brand))
 }
 
 func (h Handler) GetBrand(w http.ResponseWriter, req *http.Request) {
-	// Consider restricting method
-	// if req.Method != "GET" {
-	// 	w.WriteHeader(http.StatusMethodNotAllowed)
-	// }
-
 	name := req.URL.Query().Get("name")
 	if name == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		// TODO write error json response
+		writeProblem(w, req, http.StatusBadRequest, CodeMissingParameter, "name", "name is required")
 		return
 	}
 
 	brand, err := h.svc.GetBrand(req.Context(), name)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, GetBrandResponse(brand))
+}
+
+func (h Handler) AddPrice(w http.ResponseWriter, req *http.Request) {
+	var apr AddPriceRequest
+	if !decodeJSON(w, req, &apr) {
 		return
 	}
 
-	res, err := json.Marshal(GetBrandResponse(brand))
+	price, err := h.svc.AddPrice(req.Context(), Price{
+		BrandID:   apr.BrandID,
+		StartDate: apr.StartDate.UTC(),
+		EndDate:   apr.EndDate.UTC(),
+		ProductID: apr.ProductID,
+		Priority:  apr.Priority,
+		Price:     apr.Price,
+	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusCreated, AddPriceResponse(price))
+}
+
+// BatchCreatePrices adds every price in the request or none of them. Rows that
+// fail are listed by their index in the problem details errors.
+func (h Handler) BatchCreatePrices(w http.ResponseWriter, req *http.Request) {
+	var bcr BatchCreatePricesRequest
+	if !decodeJSON(w, req, &bcr) {
 		return
 	}
 
-	w.Header().Set("content-type", "application/json")
+	prices := make([]Price, 0, len(bcr.Prices))
+	for _, apr := range bcr.Prices {
+		prices = append(prices, Price{
+			BrandID:   apr.BrandID,
+			StartDate: apr.StartDate.UTC(),
+			EndDate:   apr.EndDate.UTC(),
+			ProductID: apr.ProductID,
+			Priority:  apr.Priority,
+			Price:     apr.Price,
+		})
+	}
 
-	_, err = w.Write(res)
+	added, err := h.svc.AddPrices(req.Context(), prices)
 	if err != nil {
-		// TODO: log error
-		return // silences staticcheck
+		writeError(w, req, err)
+		return
+	}
+
+	res := BatchCreatePricesResponse{Prices: make([]AddPriceResponse, 0, len(added))}
+	for _, price := range added {
+		res.Prices = append(res.Prices, AddPriceResponse(price))
 	}
+
+	writeJSON(w, req, http.StatusCreated, res)
 }
 
-func (h Handler) AddPrice(w http.ResponseWriter, req *http.Request) {
-	// TODO, not implemented.
-	w.WriteHeader(http.StatusNotImplemented)
+// ImportPrices adds every price of a text/csv price list, up to
+// maxCSVBodyBytes, or none of them. Rows that fail are listed by their line
+// number in the problem details errors.
+func (h Handler) ImportPrices(w http.ResponseWriter, req *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("content-type"))
+	if err != nil || mediaType != "text/csv" {
+		writeProblem(w, req, http.StatusUnsupportedMediaType, CodeInvalidBody, "", "content-type must be text/csv")
+		return
+	}
+
+	added, err := h.svc.ImportPricesCSV(req.Context(), http.MaxBytesReader(w, req.Body, maxCSVBodyBytes))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	res := BatchCreatePricesResponse{Prices: make([]AddPriceResponse, 0, len(added))}
+	for _, price := range added {
+		res.Prices = append(res.Prices, AddPriceResponse(price))
+	}
+
+	writeJSON(w, req, http.StatusCreated, res)
+}
+
+// ExportPrices responds with the stored prices as a text/csv price list,
+// optionally filtered by brand_id and product_id.
+func (h Handler) ExportPrices(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	var filter PriceFilter
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{
+		{"brand_id", &filter.BrandID},
+		{"product_id", &filter.ProductID},
+	} {
+		if query.Get(param.name) == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(query.Get(param.name))
+		if err != nil {
+			writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, param.name, param.name+" must be an integer")
+			return
+		}
+		*param.dst = id
+	}
+
+	prices, err := h.svc.ListPrices(req.Context(), filter)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := WritePricesCSV(&buf, prices); err != nil {
+		writeProblem(w, req, http.StatusInternalServerError, CodeInternal, "", "failed to encode response")
+		return
+	}
+
+	w.Header().Set("content-type", "text/csv")
+	w.Header().Set("content-disposition", `attachment; filename="prices.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		requestLogger(req).Warn("failed to write response", slog.Any("error", err))
+	}
 }
 
 func (h Handler) GetPrice(w http.ResponseWriter, req *http.Request) {
-	// Consider restricting method
-	// if req.Method != "GET" {
-	// 	w.WriteHeader(http.StatusMethodNotAllowed)
-	// }
+	query := req.URL.Query()
+
+	// string_id is a custom identifier supplied by clients and echoed back.
+	for _, param := range []string{"brand_id", "product_id", "date", "string_id"} {
+		if query.Get(param) == "" {
+			writeProblem(w, req, http.StatusBadRequest, CodeMissingParameter, param, param+" is required")
+			return
+		}
+	}
+
+	bid, err := strconv.Atoi(query.Get("brand_id"))
+	if err != nil {
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "brand_id", "brand_id must be an integer")
+		return
+	}
+
+	pid, err := strconv.Atoi(query.Get("product_id"))
+	if err != nil {
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "product_id", "product_id must be an integer")
+		return
+	}
+
+	date, err := time.Parse(time.RFC3339, query.Get("date"))
+	if err != nil {
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "date", "date must be in RFC3339 format, e.g: 2020-06-14T10:00:00Z")
+		return
+	}
+
+	var lowestPriorPrice bool
+	if query.Get("lowest_prior_price") != "" {
+		lowestPriorPrice, err = strconv.ParseBool(query.Get("lowest_prior_price"))
+		if err != nil {
+			writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "lowest_prior_price", "lowest_prior_price must be true or false")
+			return
+		}
+	}
+
+	price, err := h.svc.GetPrice(req.Context(), bid, pid, date)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	res := newGetPriceResponse(price, query.Get("string_id"))
+	if lowestPriorPrice {
+		res.LowestPriorPrice = h.lowestPriorPrice(req, bid, pid, price)
+	}
+
+	writeJSON(w, req, http.StatusOK, res)
+}
+
+// lowestPriorPrice returns the lowest price applied before price started, or
+// nil if none applied in the same currency. The price was already found so
+// failures are logged and the optional field omitted rather than failing the
+// request.
+func (h Handler) lowestPriorPrice(req *http.Request, brandID, productID int, price FinalPrice) *Money {
+	// the window ends when the price, or promotion, started applying so it's
+	// the same throughout the effective window.
+	lowest, err := h.svc.GetLowestPriorPrice(req.Context(), brandID, productID, price.EffectiveStartDate, LowestPriorPriceWindow)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil // nothing applied before, e.g: a new product
+	case err != nil:
+		requestLogger(req).Warn("failed to get lowest prior price", slog.Int("brand_id", brandID), slog.Int("product_id", productID), slog.Any("error", err))
+		return nil
+	case lowest.Price.Currency != price.Price.Currency:
+		return nil
+	}
+
+	return &lowest.Price
+}
+
+func newGetPriceResponse(price FinalPrice, stringID string) GetPriceResponse {
+	res := GetPriceResponse{
+		PriceID:   price.ID,
+		BrandID:   price.BrandID,
+		ProductID: price.ProductID,
+		Price:     price.Price,
+
+		OriginalPrice: price.OriginalPrice,
+		Discount:      price.Discount,
+		PromotionID:   price.PromotionID,
+		StartDate:     price.StartDate,
+		EndDate:       price.EndDate,
+
+		EffectiveStartDate: price.EffectiveStartDate,
+		EffectiveEndDate:   price.EffectiveEndDate,
+		StringID:           stringID,
+	}
+	if price.CompareAtPriceID != 0 {
+		compareAt := price.CompareAtPrice
+		res.CompareAtPriceID = price.CompareAtPriceID
+		res.CompareAtPrice = &compareAt
+	}
+
+	return res
+}
 
-	brandID := req.URL.Query().Get("brand_id")
-	if brandID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		// TODO write error json response
+// BatchGetPrices looks up the prices of many products at once. The response is
+// 200 OK when the batch succeeds even if some products have no price, their
+// results hold an error instead.
+func (h Handler) BatchGetPrices(w http.ResponseWriter, req *http.Request) {
+	var bgr BatchGetPricesRequest
+	if !decodeJSON(w, req, &bgr) {
 		return
 	}
 
-	productID := req.URL.Query().Get("product_id")
-	if productID == "" {
-		w.WriteHeader(http.StatusBadRequest)
+	if bgr.Date.IsZero() {
+		writeProblemDetails(w, req, Problem{Status: http.StatusBadRequest, Code: CodeMissingParameter, Param: "date", Detail: "date is required"}, bgr.StringID)
 		return
 	}
 
-	date := req.URL.Query().Get("date")
-	if date == "" {
-		w.WriteHeader(http.StatusBadRequest)
+	results, err := h.svc.GetPrices(req.Context(), bgr.BrandID, bgr.ProductIDs, bgr.Date.UTC())
+	if err != nil {
+		writeErrorWithStringID(w, req, err, bgr.StringID)
 		return
 	}
 
-	formattedDate, err := time.Parse(time.RFC3339, date)
+	res := BatchGetPricesResponse{
+		Results:  make([]BatchGetPriceResult, 0, len(results)),
+		StringID: bgr.StringID,
+	}
+	for _, result := range results {
+		item := BatchGetPriceResult{ProductID: result.ProductID}
+		if result.Err != nil {
+			p := problemFor(req, errorProblem(result.Err), bgr.StringID)
+			item.Error = &p
+		} else {
+			pr := newGetPriceResponse(result.Price, bgr.StringID)
+			item.Price = &pr
+		}
+		res.Results = append(res.Results, item)
+	}
+
+	writeJSON(w, req, http.StatusOK, res)
+}
+
+func (h Handler) GetPriceTimeline(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	for _, param := range []string{"brand_id", "product_id", "from", "to"} {
+		if query.Get(param) == "" {
+			writeProblem(w, req, http.StatusBadRequest, CodeMissingParameter, param, param+" is required")
+			return
+		}
+	}
+
+	bid, err := strconv.Atoi(query.Get("brand_id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "brand_id", "brand_id must be an integer")
 		return
 	}
 
-	// custom string identifier mentioned in pdf
-	stringID := req.URL.Query().Get("string_id")
-	if stringID == "" {
-		w.WriteHeader(http.StatusBadRequest)
+	pid, err := strconv.Atoi(query.Get("product_id"))
+	if err != nil {
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "product_id", "product_id must be an integer")
 		return
 	}
 
-	bid, err := strconv.Atoi(brandID)
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "from", "from must be in RFC3339 format, e.g: 2020-06-14T10:00:00Z")
 		return
 	}
 
-	pid, err := strconv.Atoi(productID)
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "to", "to must be in RFC3339 format, e.g: 2020-06-14T10:00:00Z")
 		return
 	}
 
-	price, err := h.svc.GetPrice(req.Context(), bid, pid, formattedDate)
+	segments, err := h.svc.GetPriceTimeline(req.Context(), bid, pid, from.UTC(), to.UTC())
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		writeError(w, req, err)
 		return
 	}
 
-	res, err := json.Marshal(GetPriceResponse{
-		BrandID:   price.BrandID,
-		ProductID: price.ProductID,
-		Price:     fmt.Sprintf("%d.%02d", price.Price/100, price.Price%100), // TODO: use money.Money
-		Curr:      price.Curr,
-		StartDate: price.StartDate.String(),
-		EndDate:   price.EndDate.String(),
-		StringID:  stringID,
+	res := GetPriceTimelineResponse{
+		BrandID:   bid,
+		ProductID: pid,
+		Segments:  make([]PriceSegmentResponse, 0, len(segments)),
+	}
+	for _, seg := range segments {
+		sr := PriceSegmentResponse{
+			StartDate: seg.StartDate,
+			EndDate:   seg.EndDate,
+		}
+		if seg.PriceID != 0 {
+			price := seg.Price
+			sr.PriceID = seg.PriceID
+			sr.Price = &price
+		}
+		res.Segments = append(res.Segments, sr)
+	}
+
+	writeJSON(w, req, http.StatusOK, res)
+}
+
+// pathID parses the {id} path wildcard, writing a problem details response and
+// returning false if it isn't a positive integer.
+func pathID(w http.ResponseWriter, req *http.Request) (int, bool) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil || id <= 0 {
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidParameter, "id", "id must be a positive integer")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h Handler) RenameBrand(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	var rbr RenameBrandRequest
+	if !decodeJSON(w, req, &rbr) {
+		return
+	}
+
+	brand, err := h.svc.RenameBrand(req.Context(), id, rbr.Name)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, RenameBrandResponse(brand))
+}
+
+func (h Handler) DeleteBrand(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteBrand(req.Context(), id); err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Handler) GetPriceByID(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	price, err := h.svc.GetPriceByID(req.Context(), id)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, GetPriceByIDResponse(price))
+}
+
+func (h Handler) UpdatePrice(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	var upr UpdatePriceRequest
+	if !decodeJSON(w, req, &upr) {
+		return
+	}
+
+	price, err := h.svc.UpdatePrice(req.Context(), Price{
+		ID:        id,
+		BrandID:   upr.BrandID,
+		StartDate: upr.StartDate.UTC(),
+		EndDate:   upr.EndDate.UTC(),
+		ProductID: upr.ProductID,
+		Priority:  upr.Priority,
+		Price:     upr.Price,
 	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, UpdatePriceResponse(price))
+}
+
+func (h Handler) PatchPrice(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
 		return
 	}
 
-	w.Header().Set("content-type", "application/json")
+	var ppr PatchPriceRequest
+	if !decodeJSON(w, req, &ppr) {
+		return
+	}
 
-	_, err = w.Write(res)
+	price, err := h.svc.PatchPrice(req.Context(), id, PricePatch(ppr))
 	if err != nil {
-		// TODO: log error
-		return // silences staticcheck
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, UpdatePriceResponse(price))
+}
+
+func (h Handler) DeletePrice(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeletePrice(req.Context(), id); err != nil {
+		writeError(w, req, err)
+		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Handler) AddPromotion(w http.ResponseWriter, req *http.Request) {
+	var apr AddPromotionRequest
+	if !decodeJSON(w, req, &apr) {
+		return
+	}
+
+	promotion := Promotion{
+		BrandID:    apr.BrandID,
+		ProductIDs: apr.ProductIDs,
+		StartDate:  apr.StartDate.UTC(),
+		EndDate:    apr.EndDate.UTC(),
+		PercentOff: apr.PercentOff,
+	}
+	if apr.AmountOff != nil {
+		promotion.AmountOff = *apr.AmountOff
+	}
+
+	promotion, err := h.svc.AddPromotion(req.Context(), promotion)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusCreated, newAddPromotionResponse(promotion))
+}
+
+func newAddPromotionResponse(promotion Promotion) AddPromotionResponse {
+	res := AddPromotionResponse{
+		ID:         promotion.ID,
+		BrandID:    promotion.BrandID,
+		ProductIDs: promotion.ProductIDs,
+		StartDate:  promotion.StartDate,
+		EndDate:    promotion.EndDate,
+		PercentOff: promotion.PercentOff,
+	}
+	if promotion.AmountOff != (Money{}) {
+		amountOff := promotion.AmountOff
+		res.AmountOff = &amountOff
+	}
+
+	return res
+}
+
+func (h Handler) GetPromotionByID(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.svc.GetPromotionByID(req.Context(), id)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, GetPromotionByIDResponse(newAddPromotionResponse(promotion)))
+}
+
+func (h Handler) DeletePromotion(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeletePromotion(req.Context(), id); err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -1,10 +1,16 @@
 package pricing_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -83,13 +89,18 @@ func TestAPIGetPrice(t *testing.T) {
 	}
 	// per MockRepository in ./repository.go
 	want := pricing.GetPriceResponse{
+		PriceID:   1,
 		BrandID:   input.BrandID,
 		ProductID: input.ProductID,
-		StartDate: "2020-06-14 10:00:00 +0000 UTC",
-		EndDate:   "2020-06-15 10:00:00 +0000 UTC",
-		Price:     "1.00",
-		Curr:      "USD",
-		StringID:  input.StringID,
+		StartDate: time.Date(2020, 06, 14, 10, 00, 00, 0, time.UTC),
+		EndDate:   time.Date(2020, 06, 15, 10, 00, 00, 0, time.UTC),
+		Price:     pricing.Money{Amount: 100, Currency: "USD"},
+
+		OriginalPrice:      pricing.Money{Amount: 100, Currency: "USD"},
+		Discount:           pricing.Money{Currency: "USD"},
+		EffectiveStartDate: time.Date(2020, 06, 14, 10, 00, 00, 0, time.UTC),
+		EffectiveEndDate:   time.Date(2020, 06, 15, 10, 00, 00, 0, time.UTC),
+		StringID:           input.StringID,
 	}
 
 	url := fmt.Sprintf("%s/api/v1/prices?brand_id=%d&product_id=%d&date=%s&string_id=%s", ts.URL, input.BrandID, input.ProductID, input.Date.Format(time.RFC3339), input.StringID)
@@ -116,3 +127,930 @@ func TestAPIGetPrice(t *testing.T) {
 
 	resp.Body.Close()
 }
+
+func newInMemoryHandler(t *testing.T) *pricing.Handler {
+	t.Helper()
+
+	repo, err := pricing.NewInMemoryRepository(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := pricing.NewHandler(pricing.NewService(repo))
+	if err != nil {
+		t.Fatalf("unable to create handler with in-memory repository: %v", err)
+	}
+
+	return h
+}
+
+func TestAPIAddBrand(t *testing.T) {
+	t.Parallel()
+
+	h := newInMemoryHandler(t)
+	ts := httptest.NewServer(http.HandlerFunc(h.AddBrand))
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	// Subtests run in order and share the repository so the duplicate is
+	// detected.
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       pricing.AddBrandResponse
+	}{
+		{"valid", `{"name":"EXAMPLE"}`, http.StatusCreated, pricing.AddBrandResponse{ID: 1, Name: "EXAMPLE"}},
+		{"second brand", `{"name":"OTHER"}`, http.StatusCreated, pricing.AddBrandResponse{ID: 2, Name: "OTHER"}},
+		{"duplicate", `{"name":"EXAMPLE"}`, http.StatusConflict, pricing.AddBrandResponse{}},
+		{"empty name", `{"name":" "}`, http.StatusBadRequest, pricing.AddBrandResponse{}},
+		{"unknown field", `{"name":"NEW","id":3}`, http.StatusBadRequest, pricing.AddBrandResponse{}},
+		{"malformed json", `{"name":`, http.StatusBadRequest, pricing.AddBrandResponse{}},
+		{"body too large", `{"name":"` + strings.Repeat("a", 1<<20) + `"}`, http.StatusRequestEntityTooLarge, pricing.AddBrandResponse{}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/api/v1/brands", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("want: %d - got: %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var got pricing.AddBrandResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error decoding json response: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("AddBrand mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIAddPrice(t *testing.T) {
+	t.Parallel()
+
+	h := newInMemoryHandler(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/brands", h.AddBrand)
+	mux.HandleFunc("POST /api/v1/prices", h.AddPrice)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	resp, err := http.Post(ts.URL+"/api/v1/brands", "application/json", strings.NewReader(`{"name":"EXAMPLE"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	valid := `{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}`
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       pricing.AddPriceResponse
+	}{
+		{"valid", valid, http.StatusCreated, pricing.AddPriceResponse{
+			ID:        1,
+			BrandID:   1,
+			StartDate: time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+			ProductID: 35455,
+			Priority:  0,
+			Price:     pricing.Money{Amount: 3550, Currency: "EUR"},
+		}},
+		{"zero decimal currency", `{"brand_id":1,"start_date":"2020-06-14T00:00:00+02:00","end_date":"2020-06-15T00:00:00+02:00","product_id":1,"priority":0,"price":{"amount":"3550","currency":"JPY"}}`, http.StatusCreated, pricing.AddPriceResponse{
+			ID:        2,
+			BrandID:   1,
+			StartDate: time.Date(2020, 06, 13, 22, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2020, 06, 14, 22, 0, 0, 0, time.UTC),
+			ProductID: 1,
+			Priority:  0,
+			Price:     pricing.Money{Amount: 3550, Currency: "JPY"},
+		}},
+		{"brand doesn't exist", strings.Replace(valid, `"brand_id":1`, `"brand_id":2`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"brand id zero", strings.Replace(valid, `"brand_id":1`, `"brand_id":0`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"product id zero", strings.Replace(valid, `"product_id":35455`, `"product_id":0`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"start after end", strings.Replace(valid, `2020-06-14T00:00:00Z`, `2021-01-01T00:00:00Z`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"missing end date", strings.Replace(valid, `"end_date":"2020-12-31T23:59:59Z",`, ``, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"negative price", strings.Replace(valid, `"35.50"`, `"-35.50"`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"unknown currency", strings.Replace(valid, `"EUR"`, `"EURO"`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"too many decimals", strings.Replace(valid, `"35.50"`, `"35.505"`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+		{"malformed date", strings.Replace(valid, `2020-06-14T00:00:00Z`, `2020-06-14`, 1), http.StatusBadRequest, pricing.AddPriceResponse{}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/api/v1/prices", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("want: %d - got: %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var got pricing.AddPriceResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error decoding json response: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("AddPrice mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIGetPriceProblems(t *testing.T) {
+	t.Parallel()
+
+	h := newInMemoryHandler(t)
+	ts := httptest.NewServer(http.HandlerFunc(h.GetPrice))
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	testCases := map[string]struct {
+		query string
+		want  pricing.Problem
+	}{
+		"missing brand_id": {
+			query: "product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1",
+			want:  pricing.Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "brand_id is required", Instance: "/api/v1/prices", Code: pricing.CodeMissingParameter, Param: "brand_id", StringID: "test_1"},
+		},
+		"missing string_id": {
+			query: "brand_id=1&product_id=1&date=2020-06-14T10:00:00Z",
+			want:  pricing.Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "string_id is required", Instance: "/api/v1/prices", Code: pricing.CodeMissingParameter, Param: "string_id"},
+		},
+		"brand_id not an integer": {
+			query: "brand_id=one&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1",
+			want:  pricing.Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "brand_id must be an integer", Instance: "/api/v1/prices", Code: pricing.CodeInvalidParameter, Param: "brand_id", StringID: "test_1"},
+		},
+		"date not RFC3339": {
+			query: "brand_id=1&product_id=1&date=2020-06-14&string_id=test_1",
+			want:  pricing.Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "date must be in RFC3339 format, e.g: 2020-06-14T10:00:00Z", Instance: "/api/v1/prices", Code: pricing.CodeInvalidParameter, Param: "date", StringID: "test_1"},
+		},
+		"lowest_prior_price not a bool": {
+			query: "brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1&lowest_prior_price=yes",
+			want:  pricing.Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "lowest_prior_price must be true or false", Instance: "/api/v1/prices", Code: pricing.CodeInvalidParameter, Param: "lowest_prior_price", StringID: "test_1"},
+		},
+		"no price found": {
+			query: "brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1",
+			want:  pricing.Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "not found: no price for brand_id: 1, product_id: 1 at: 2020-06-14T10:00:00Z", Instance: "/api/v1/prices", Code: pricing.CodeNotFound, StringID: "test_1"},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + "/api/v1/prices?" + tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want.Status {
+				t.Errorf("want: %d - got: %d", tt.want.Status, resp.StatusCode)
+			}
+
+			if ct := resp.Header.Get("content-type"); ct != "application/problem+json" {
+				t.Errorf("unexpected content-type: %s", ct)
+			}
+
+			var got pricing.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error decoding json response: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("problem mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIGetPriceTimeline(t *testing.T) {
+	t.Parallel()
+
+	repo, err := pricing.NewInMemoryRepository(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pricing.SeedExampleData(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := pricing.NewHandler(pricing.NewService(repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	testCases := map[string]struct {
+		query      string
+		wantStatus int
+		want       string
+	}{
+		"priorities": {
+			query:      "brand_id=1&product_id=35455&from=2020-06-14T14:00:00Z&to=2020-06-14T16:00:00Z",
+			wantStatus: http.StatusOK,
+			want:       `{"brand_id":1,"product_id":35455,"segments":[{"start_date":"2020-06-14T14:00:00Z","end_date":"2020-06-14T14:59:59.999999999Z","price_id":1,"price":{"amount":"35.50","currency":"EUR"}},{"start_date":"2020-06-14T15:00:00Z","end_date":"2020-06-14T16:00:00Z","price_id":2,"price":{"amount":"25.45","currency":"EUR"}}]}`,
+		},
+		"gap": {
+			query:      "brand_id=1&product_id=1&from=2020-06-14T14:00:00Z&to=2020-06-14T16:00:00Z",
+			wantStatus: http.StatusOK,
+			want:       `{"brand_id":1,"product_id":1,"segments":[{"start_date":"2020-06-14T14:00:00Z","end_date":"2020-06-14T16:00:00Z"}]}`,
+		},
+		"missing to": {
+			query:      "brand_id=1&product_id=35455&from=2020-06-14T14:00:00Z",
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"to is required","instance":"/api/v1/prices/timeline","code":"missing_parameter","param":"to"}`,
+		},
+		"to before from": {
+			query:      "brand_id=1&product_id=35455&from=2020-06-14T14:00:00Z&to=2020-06-14T13:00:00Z",
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"cannot be before from","instance":"/api/v1/prices/timeline","code":"invalid_parameter","param":"to"}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + "/api/v1/prices/timeline?" + tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("want: %d - got: %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIBatchCreatePrices(t *testing.T) {
+	t.Parallel()
+
+	h := newInMemoryHandler(t)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	resp, err := http.Post(ts.URL+"/api/v1/brands", "application/json", strings.NewReader(`{"name":"EXAMPLE"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       string
+	}{
+		{
+			name:       "invalid rows",
+			body:       `{"prices":[{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":1,"priority":0,"price":{"amount":"1.00","currency":"EUR"}},{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-13T00:00:00Z","product_id":1,"priority":1,"price":{"amount":"1.00","currency":"EUR"}},{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":0,"priority":2,"price":{"amount":"1.00","currency":"EUR"}}]}`,
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"2 batch rows failed, first: row 1: end_date: cannot be before start_date","instance":"/api/v1/prices:batchCreate","code":"invalid_parameter","errors":[{"index":1,"code":"invalid_parameter","param":"end_date","detail":"cannot be before start_date"},{"index":2,"code":"invalid_parameter","param":"product_id","detail":"must be greater than 0"}]}`,
+		},
+		{
+			name:       "created",
+			body:       `{"prices":[{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":1,"priority":0,"price":{"amount":"1.00","currency":"EUR"}},{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":1,"priority":1,"price":{"amount":"2.00","currency":"EUR"}}]}`,
+			wantStatus: http.StatusCreated,
+			want:       `{"prices":[{"id":1,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":1,"priority":0,"price":{"amount":"1.00","currency":"EUR"}},{"id":2,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-06-15T00:00:00Z","product_id":1,"priority":1,"price":{"amount":"2.00","currency":"EUR"}}]}`,
+		},
+		{
+			name:       "conflicts with existing and other rows",
+			body:       `{"prices":[{"brand_id":1,"start_date":"2020-06-14T12:00:00Z","end_date":"2020-06-16T00:00:00Z","product_id":1,"priority":0,"price":{"amount":"1.00","currency":"EUR"}},{"brand_id":1,"start_date":"2020-06-16T00:00:00Z","end_date":"2020-06-17T00:00:00Z","product_id":1,"priority":0,"price":{"amount":"1.00","currency":"EUR"}}]}`,
+			wantStatus: http.StatusConflict,
+			want:       `{"type":"about:blank","title":"Conflict","status":409,"detail":"2 batch rows failed, first: row 0: conflict: price overlaps prices with the same priority, ids: [1]","instance":"/api/v1/prices:batchCreate","code":"conflict","errors":[{"index":0,"code":"conflict","detail":"conflict: price overlaps prices with the same priority, ids: [1]","conflicting_ids":[1]},{"index":1,"code":"conflict","detail":"conflict: price overlaps rows [0] of the batch with the same priority"}]}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		resp, err := http.Post(ts.URL+"/api/v1/prices:batchCreate", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		got, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: want: %d - got: %d", tt.name, tt.wantStatus, resp.StatusCode)
+		}
+
+		if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+			t.Errorf("%s: response mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestAPIImportExportPrices(t *testing.T) {
+	t.Parallel()
+
+	h := newInMemoryHandler(t)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	resp, err := http.Post(ts.URL+"/api/v1/brands", "application/json", strings.NewReader(`{"name":"EXAMPLE"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	const header = "BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n"
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        string
+	}{
+		{
+			name:        "not csv",
+			contentType: "application/json",
+			body:        `{}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			want:        `{"type":"about:blank","title":"Unsupported Media Type","status":415,"detail":"content-type must be text/csv","instance":"/api/v1/prices/import","code":"invalid_body"}`,
+		},
+		{
+			name:        "brand does not exist",
+			contentType: "text/csv",
+			body:        header + "1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n2,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n",
+			wantStatus:  http.StatusBadRequest,
+			want:        `{"type":"about:blank","title":"Bad Request","status":400,"detail":"1 batch rows failed, first: line 3: BRAND_ID: brand does not exist","instance":"/api/v1/prices/import","code":"invalid_parameter","errors":[{"index":1,"line":3,"code":"invalid_parameter","param":"BRAND_ID","detail":"brand does not exist"}]}`,
+		},
+		{
+			name:        "body too large",
+			contentType: "text/csv",
+			body:        header + strings.Repeat("1", 32<<20),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			want:        `{"type":"about:blank","title":"Request Entity Too Large","status":413,"detail":"request body exceeds 33554432 bytes","instance":"/api/v1/prices/import","code":"invalid_body"}`,
+		},
+		{
+			name:        "imported",
+			contentType: "text/csv; charset=utf-8",
+			body:        header + "1,2020-06-14-00.00.00,2020-12-31-23.59.59,99,35455,0,35.50,EUR\n",
+			wantStatus:  http.StatusCreated,
+			want:        `{"prices":[{"id":1,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}]}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		resp, err := http.Post(ts.URL+"/api/v1/prices/import", tt.contentType, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		got, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: want: %d - got: %d", tt.name, tt.wantStatus, resp.StatusCode)
+		}
+
+		if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+			t.Errorf("%s: response mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/prices/export?brand_id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("content-type"); ct != "text/csv" {
+		t.Errorf("unexpected content-type: %s", ct)
+	}
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := header + "1,2020-06-14-00.00.00,2020-12-31-23.59.59,1,35455,0,35.50,EUR\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("export mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAPIBatchGetPrices(t *testing.T) {
+	t.Parallel()
+
+	repo, err := pricing.NewInMemoryRepository(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pricing.SeedExampleData(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := pricing.NewHandler(pricing.NewService(repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	testCases := map[string]struct {
+		body       string
+		wantStatus int
+		want       string
+	}{
+		"found and not found": {
+			body:       `{"brand_id":1,"product_ids":[35455,1],"date":"2020-06-14T16:00:00Z","string_id":"batch_1"}`,
+			wantStatus: http.StatusOK,
+			want:       `{"results":[{"product_id":35455,"price":{"price_id":2,"brand_id":1,"product_id":35455,"price":{"amount":"25.45","currency":"EUR"},"compare_at_price_id":1,"compare_at_price":{"amount":"35.50","currency":"EUR"},"original_price":{"amount":"25.45","currency":"EUR"},"discount":{"amount":"0.00","currency":"EUR"},"start_date":"2020-06-14T15:00:00Z","end_date":"2020-06-14T18:30:00Z","effective_start_date":"2020-06-14T15:00:00Z","effective_end_date":"2020-06-14T18:30:00Z","string_id":"batch_1"}},{"product_id":1,"error":{"type":"about:blank","title":"Not Found","status":404,"detail":"not found: no price for brand_id: 1, product_id: 1 at: 2020-06-14T16:00:00Z","instance":"/api/v1/prices:batchGet","code":"not_found","string_id":"batch_1"}}],"string_id":"batch_1"}`,
+		},
+		"empty product_ids": {
+			body:       `{"brand_id":1,"product_ids":[],"date":"2020-06-14T16:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"cannot be empty","instance":"/api/v1/prices:batchGet","code":"invalid_parameter","param":"product_ids"}`,
+		},
+		"missing date": {
+			body:       `{"brand_id":1,"product_ids":[35455]}`,
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"date is required","instance":"/api/v1/prices:batchGet","code":"missing_parameter","param":"date"}`,
+		},
+		"missing date echoes string_id": {
+			body:       `{"brand_id":1,"product_ids":[35455],"string_id":"batch_2"}`,
+			wantStatus: http.StatusBadRequest,
+			want:       `{"type":"about:blank","title":"Bad Request","status":400,"detail":"date is required","instance":"/api/v1/prices:batchGet","code":"missing_parameter","param":"date","string_id":"batch_2"}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/api/v1/prices:batchGet", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("want: %d - got: %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// errRepository returns err from every GetPrice call.
+type errRepository struct {
+	pricing.MockRepository
+	err error
+}
+
+func (er *errRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (pricing.FinalPrice, error) {
+	return pricing.FinalPrice{}, er.err
+}
+
+// timelineErrRepository returns err from every GetPriceTimeline call.
+type timelineErrRepository struct {
+	pricing.MockRepository
+	err error
+}
+
+func (tr *timelineErrRepository) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]pricing.PriceSegment, error) {
+	return nil, tr.err
+}
+
+func TestAPILowestPriorPrice(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		repo  pricing.Repository
+		query string
+		want  *pricing.Money
+	}{
+		"not requested": {
+			repo:  pricing.NewMockRepository(),
+			query: "",
+		},
+		"requested": {
+			repo:  pricing.NewMockRepository(),
+			query: "&lowest_prior_price=true",
+			want:  &pricing.Money{Amount: 100, Currency: "USD"},
+		},
+		"not found": {
+			repo:  &timelineErrRepository{err: fmt.Errorf("%w: no price", pricing.ErrNotFound)},
+			query: "&lowest_prior_price=true",
+		},
+		"lookup failed": {
+			repo:  &timelineErrRepository{err: fmt.Errorf("%w: database down", pricing.ErrUnavailable)},
+			query: "&lowest_prior_price=true",
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h, err := pricing.NewHandler(pricing.NewService(tt.repo))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/prices?brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			h.GetPrice(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("want: %d - got: %d", http.StatusOK, rec.Code)
+			}
+
+			var got pricing.GetPriceResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error decoding json response: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got.LowestPriorPrice); diff != "" {
+				t.Errorf("lowest_prior_price mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPIErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err      error
+		want     int
+		wantCode string
+	}{
+		"not found":        {fmt.Errorf("%w: no price", pricing.ErrNotFound), http.StatusNotFound, pricing.CodeNotFound},
+		"conflict":         {fmt.Errorf("%w: clash", pricing.ErrConflict), http.StatusConflict, pricing.CodeConflict},
+		"invalid argument": {fmt.Errorf("%w: bad", pricing.ErrInvalidArgument), http.StatusBadRequest, pricing.CodeInvalidParameter},
+		"validation error": {&pricing.ValidationError{Field: "brand_id", Reason: "bad"}, http.StatusBadRequest, pricing.CodeInvalidParameter},
+		"unavailable":      {fmt.Errorf("%w: database down", pricing.ErrUnavailable), http.StatusServiceUnavailable, pricing.CodeUnavailable},
+		"timeout":          {fmt.Errorf("failed to get price: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, pricing.CodeTimeout},
+		"unexpected":       {errors.New("boom"), http.StatusInternalServerError, pricing.CodeInternal},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h, err := pricing.NewHandler(pricing.NewService(&errRepository{err: tt.err}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/prices?brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1", nil)
+			rec := httptest.NewRecorder()
+			h.GetPrice(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("want: %d - got: %d", tt.want, rec.Code)
+			}
+
+			var got pricing.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error decoding json response: %v", err)
+			}
+
+			if got.Code != tt.wantCode {
+				t.Errorf("want code: %s - got: %s", tt.wantCode, got.Code)
+			}
+		})
+	}
+}
+
+func TestAPIClientCanceled(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	h, err := pricing.NewHandler(pricing.NewService(&errRepository{err: context.Canceled}), pricing.WithHandlerLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // client disconnected
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/prices?brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.AccessLog(time.Now)(http.HandlerFunc(h.GetPrice)).ServeHTTP(rec, req)
+
+	if rec.Code != 499 {
+		t.Errorf("want: 499 - got: %d", rec.Code)
+	}
+	if strings.Contains(logs.String(), `"level":"ERROR"`) {
+		t.Errorf("want no error logs - got:\n%s", logs.String())
+	}
+}
+
+func TestAPIUpdateDelete(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	newInMemoryHandler(t).RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	price := `{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}`
+
+	// Steps run in order against the same repository.
+	steps := []struct {
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{http.MethodPost, "/api/v1/brands", `{"name":"EXAMPLE"}`, http.StatusCreated, `{"id":1,"name":"EXAMPLE"}`},
+		{http.MethodPost, "/api/v1/prices", price, http.StatusCreated, ""},
+		{http.MethodPost, "/api/v1/prices", price, http.StatusConflict, `{"type":"about:blank","title":"Conflict","status":409,"detail":"conflict: price overlaps prices with the same priority, ids: [1]","instance":"/api/v1/prices","code":"conflict","conflicting_ids":[1]}`},
+		{http.MethodGet, "/api/v1/prices/1", "", http.StatusOK, `{"id":1,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}`},
+		{http.MethodPut, "/api/v1/prices/1", strings.Replace(price, `"priority":0`, `"priority":2`, 1), http.StatusOK, `{"id":1,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":2,"price":{"amount":"35.50","currency":"EUR"}}`},
+		{http.MethodPatch, "/api/v1/prices/1", `{"price":{"amount":"30.00","currency":"EUR"}}`, http.StatusOK, `{"id":1,"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":2,"price":{"amount":"30.00","currency":"EUR"}}`},
+		{http.MethodPatch, "/api/v1/prices/1", `{"end_date":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest, ""},
+		{http.MethodPatch, "/api/v1/prices/2", `{"priority":1}`, http.StatusNotFound, ""},
+		{http.MethodPut, "/api/v1/prices/one", price, http.StatusBadRequest, ""},
+		{http.MethodPatch, "/api/v1/brands/1", `{"name":"RENAMED"}`, http.StatusOK, `{"id":1,"name":"RENAMED"}`},
+		{http.MethodGet, "/api/v1/brands?name=RENAMED", "", http.StatusOK, `{"id":1,"name":"RENAMED"}`},
+		{http.MethodDelete, "/api/v1/brands/1", "", http.StatusConflict, ""},
+		{http.MethodDelete, "/api/v1/prices/1", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/api/v1/prices/1", "", http.StatusNotFound, ""},
+		{http.MethodDelete, "/api/v1/brands/1", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/api/v1/brands/1", "", http.StatusNotFound, ""},
+	}
+
+	for _, step := range steps {
+		req, err := http.NewRequest(step.method, ts.URL+step.path, strings.NewReader(step.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != step.wantStatus {
+			t.Fatalf("%s %s want: %d - got: %d: %s", step.method, step.path, step.wantStatus, resp.StatusCode, body)
+		}
+
+		if step.wantBody != "" && string(body) != step.wantBody {
+			t.Errorf("%s %s want body: %s - got: %s", step.method, step.path, step.wantBody, body)
+		}
+	}
+}
+
+func TestAPIPromotions(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	newInMemoryHandler(t).RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	price := `{"brand_id":1,"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}`
+	promotion := `{"brand_id":1,"product_ids":[35455],"start_date":"2020-06-14T10:00:00Z","end_date":"2020-06-14T12:00:00Z","percent_off":10}`
+	getPrice := "/api/v1/prices?brand_id=1&product_id=35455&date=2020-06-14T11:00:00Z&string_id=test_1&lowest_prior_price=true"
+
+	// Steps run in order against the same repository.
+	steps := []struct {
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{http.MethodPost, "/api/v1/brands", `{"name":"EXAMPLE"}`, http.StatusCreated, ""},
+		{http.MethodPost, "/api/v1/prices", price, http.StatusCreated, ""},
+		{http.MethodPost, "/api/v1/promotions", strings.Replace(promotion, `"percent_off":10`, `"percent_off":10,"amount_off":{"amount":"1.00","currency":"EUR"}`, 1), http.StatusBadRequest, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"cannot be combined with percent_off","instance":"/api/v1/promotions","code":"invalid_parameter","param":"amount_off"}`},
+		{http.MethodPost, "/api/v1/promotions", strings.Replace(promotion, `"brand_id":1`, `"brand_id":2`, 1), http.StatusBadRequest, ""},
+		{http.MethodPost, "/api/v1/promotions", promotion, http.StatusCreated, `{"id":1,"brand_id":1,"product_ids":[35455],"start_date":"2020-06-14T10:00:00Z","end_date":"2020-06-14T12:00:00Z","percent_off":10}`},
+		{http.MethodGet, "/api/v1/promotions/1", "", http.StatusOK, `{"id":1,"brand_id":1,"product_ids":[35455],"start_date":"2020-06-14T10:00:00Z","end_date":"2020-06-14T12:00:00Z","percent_off":10}`},
+		{http.MethodGet, getPrice, "", http.StatusOK, `{"price_id":1,"brand_id":1,"product_id":35455,"price":{"amount":"31.95","currency":"EUR"},"original_price":{"amount":"35.50","currency":"EUR"},"discount":{"amount":"3.55","currency":"EUR"},"promotion_id":1,"lowest_prior_price":{"amount":"35.50","currency":"EUR"},"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","effective_start_date":"2020-06-14T10:00:00Z","effective_end_date":"2020-06-14T12:00:00Z","string_id":"test_1"}`},
+		{http.MethodDelete, "/api/v1/brands/1", "", http.StatusConflict, ""},
+		{http.MethodDelete, "/api/v1/promotions/1", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/api/v1/promotions/1", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/api/v1/promotions/1", "", http.StatusNotFound, ""},
+		{http.MethodGet, getPrice, "", http.StatusOK, `{"price_id":1,"brand_id":1,"product_id":35455,"price":{"amount":"35.50","currency":"EUR"},"original_price":{"amount":"35.50","currency":"EUR"},"discount":{"amount":"0.00","currency":"EUR"},"start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","effective_start_date":"2020-06-14T00:00:00Z","effective_end_date":"2020-12-31T23:59:59Z","string_id":"test_1"}`},
+	}
+
+	for _, step := range steps {
+		req, err := http.NewRequest(step.method, ts.URL+step.path, strings.NewReader(step.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != step.wantStatus {
+			t.Fatalf("%s %s want: %d - got: %d: %s", step.method, step.path, step.wantStatus, resp.StatusCode, body)
+		}
+
+		if step.wantBody != "" && string(body) != step.wantBody {
+			t.Errorf("%s %s want body: %s - got: %s", step.method, step.path, step.wantBody, body)
+		}
+	}
+}
+
+func TestAPIAccessLog(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err  error
+		want []map[string]any
+	}{
+		"ok": {
+			want: []map[string]any{
+				{"level": "INFO", "msg": "http request", "method": "GET", "path": "/api/v1/prices", "string_id": "test_1", "status": 200.0, "latency": 1e6},
+			},
+		},
+		"not found": {
+			err: pricing.ErrNotFound,
+			want: []map[string]any{
+				{"level": "INFO", "msg": "http request", "method": "GET", "path": "/api/v1/prices", "string_id": "test_1", "status": 404.0, "latency": 1e6},
+			},
+		},
+		"unexpected": {
+			err: errors.New("boom"),
+			want: []map[string]any{
+				{"level": "ERROR", "msg": "request failed", "method": "GET", "path": "/api/v1/prices", "string_id": "test_1", "error": "boom"},
+				{"level": "ERROR", "msg": "http request", "method": "GET", "path": "/api/v1/prices", "string_id": "test_1", "status": 500.0, "latency": 1e6},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+
+			h, err := pricing.NewHandler(pricing.NewService(&errRepository{err: tt.err}), pricing.WithHandlerLogger(logger))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// each call of the clock is 1ms later
+			now := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+			clock := func() time.Time {
+				now = now.Add(time.Millisecond)
+				return now
+			}
+
+			mux := http.NewServeMux()
+			h.RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/prices?brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1", nil)
+			h.AccessLog(clock)(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+			var got []map[string]any
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var record map[string]any
+				if err := dec.Decode(&record); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, record)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("access log mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// slowRepository blocks GetPrice until ctx is done.
+type slowRepository struct {
+	pricing.MockRepository
+}
+
+func (sr *slowRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (pricing.FinalPrice, error) {
+	<-ctx.Done()
+	return pricing.FinalPrice{}, fmt.Errorf("failed to get price: %w", ctx.Err())
+}
+
+func TestAPIServiceTimeout(t *testing.T) {
+	t.Parallel()
+
+	svc := pricing.NewService(&slowRepository{}, pricing.WithServiceTimeouts(10*time.Millisecond, 0))
+	h, err := pricing.NewHandler(svc, pricing.WithHandlerLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/prices?brand_id=1&product_id=1&date=2020-06-14T10:00:00Z&string_id=test_1", nil)
+	rec := httptest.NewRecorder()
+	h.GetPrice(rec, req)
+
+	want := `{"type":"about:blank","title":"Gateway Timeout","status":504,"detail":"backend timed out, retry later","instance":"/api/v1/prices","code":"timeout","string_id":"test_1"}`
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
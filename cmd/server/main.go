@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,21 +13,46 @@ import (
 )
 
 func main() {
-	log.Print("pricing server starting")
-
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	app, err := pricing.NewApp(os.Args)
+	cfg, err := pricing.LoadConfig(os.Args, os.LookupEnv)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var group errgroup.Group
+	if cfg.PrintConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	logger, err := cfg.Log.NewLogger(os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	logger.Info("pricing server starting")
+
+	app, err := pricing.NewApp(pricing.WithConfig(cfg), pricing.WithLogger(logger))
+	if err != nil {
+		logger.Error("failed to create app", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// gctx is also done if Run fails early, e.g: can't listen, so the process
+	// exits rather than waiting for a signal.
+	group, gctx := errgroup.WithContext(ctx)
 	group.Go(func() error {
-		<-ctx.Done()
+		<-gctx.Done()
+		if ctx.Err() == nil {
+			return nil // Run failed
+		}
 
-		log.Print("received OS signal to shutdown, use Ctrl+C again to force")
+		logger.Info("received OS signal to shutdown, use Ctrl+C again to force")
 
 		// reset signals so a second ctrl+c will terminate the application.
 		stop()
@@ -38,7 +64,11 @@ func main() {
 		return app.Run(ctx)
 	})
 
+	// Run joins any HTTP server and repository shutdown errors.
 	if err := group.Wait(); err != nil {
-		log.Print(err)
+		logger.Error("pricing server stopped with errors", slog.Any("error", err))
+		os.Exit(1)
 	}
+
+	logger.Info("pricing server stopped")
 }
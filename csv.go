@@ -0,0 +1,266 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVTimeLayout is the layout of START_DATE and END_DATE in price list CSV
+// files, always in UTC.
+const CSVTimeLayout = "2006-01-02-15.04.05"
+
+// csvHeader is the column layout of price list CSV files, matching the Price
+// field comments. Files may order the columns differently and omit
+// PRICE_LIST.
+var csvHeader = []string{"BRAND_ID", "START_DATE", "END_DATE", "PRICE_LIST", "PRODUCT_ID", "PRIORITY", "PRICE", "CURR"}
+
+// csvColumns maps the fields of a *ValidationError returned by Price.Validate
+// to the CSV column holding them.
+var csvColumns = map[string]string{
+	"brand_id":   "BRAND_ID",
+	"start_date": "START_DATE",
+	"end_date":   "END_DATE",
+	"product_id": "PRODUCT_ID",
+	"priority":   "PRIORITY",
+	"price":      "PRICE",
+}
+
+// ReadPricesCSV reads and validates a price list CSV file with a header row.
+// PRICE_LIST is optional and read into ID, PRICE is a decimal in the major
+// unit of CURR, e.g: 35.50 EUR. Every invalid row is reported in a
+// *BatchError with its line number.
+func ReadPricesCSV(r io.Reader) ([]Price, error) {
+	prices, _, err := readPricesCSV(r)
+
+	return prices, err
+}
+
+// readPricesCSV is ReadPricesCSV also returning the line number of each price
+// so later errors can refer to the file.
+func readPricesCSV(r io.Reader) ([]Price, []int, error) {
+	rows, err := readCSVRows(r, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prices := make([]Price, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		prices = append(prices, row.price)
+		lines = append(lines, row.line)
+	}
+
+	return prices, lines, nil
+}
+
+// csvBrandColumn is the optional brand name column of seed CSV files. Rows
+// naming their brand may leave BRAND_ID empty, it's resolved when seeding.
+const csvBrandColumn = "BRAND"
+
+// csvRow is a valid price read from a CSV file with its line number and, if
+// brandNames, its brand name.
+type csvRow struct {
+	price Price
+	line  int
+	brand string
+}
+
+// readCSVRows reads and validates a price list CSV file, see ReadPricesCSV.
+// With brandNames the BRAND column is read too, see csvBrandColumn.
+func readCSVRows(r io.Reader, brandNames bool) ([]csvRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &ValidationError{Field: "csv", Reason: "missing header row"}
+	}
+	if err != nil {
+		return nil, csvReadError(err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	if !brandNames {
+		delete(columns, csvBrandColumn)
+	}
+	_, named := columns[csvBrandColumn]
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok && name != "PRICE_LIST" && (name != "BRAND_ID" || !named) {
+			return nil, &ValidationError{Field: "csv", Reason: "header is missing column: " + name}
+		}
+	}
+
+	var rows []csvRow
+	var rowErrs []RowError
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvReadError(err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		index := len(rows) + len(rowErrs)
+
+		var brand string
+		if i, ok := columns[csvBrandColumn]; ok {
+			brand = strings.TrimSpace(record[i])
+		}
+
+		price, err := parseCSVRecord(record, columns, brand != "")
+		switch {
+		case err != nil:
+		case brand != "":
+			err = price.validateExceptBrand()
+		default:
+			err = price.Validate()
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Index: index, Line: line, Err: csvRowError(err)})
+			continue
+		}
+
+		rows = append(rows, csvRow{price: price, line: line, brand: brand})
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, &BatchError{Rows: rowErrs}
+	}
+
+	return rows, nil
+}
+
+// csvReadError returns a *ValidationError for malformed CSV, otherwise the
+// error reading r such as the request body exceeding its limit.
+func csvReadError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return &ValidationError{Field: "csv", Reason: err.Error()}
+	}
+
+	return fmt.Errorf("failed to read csv: %w", err)
+}
+
+// csvRowError renames the field of a *ValidationError to its CSV column.
+func csvRowError(err error) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) && csvColumns[ve.Field] != "" {
+		return &ValidationError{Field: csvColumns[ve.Field], Reason: ve.Reason}
+	}
+
+	return err
+}
+
+// parseCSVRecord parses the fields of a single row, returning a
+// *ValidationError naming the column of the first invalid field. An empty
+// BRAND_ID is allowed if the row is named, i.e: has a brand name.
+func parseCSVRecord(record []string, columns map[string]int, named bool) (Price, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	integer := func(name string, dst *int) error {
+		n, err := strconv.Atoi(field(name))
+		if err != nil {
+			return &ValidationError{Field: name, Reason: "must be an integer"}
+		}
+		*dst = n
+
+		return nil
+	}
+
+	date := func(name string, dst *time.Time) error {
+		t, err := time.Parse(CSVTimeLayout, field(name))
+		if err != nil {
+			return &ValidationError{Field: name, Reason: "must be in " + CSVTimeLayout + " format"}
+		}
+		*dst = t.UTC()
+
+		return nil
+	}
+
+	var p Price
+	if field("PRICE_LIST") != "" {
+		if err := integer("PRICE_LIST", &p.ID); err != nil {
+			return Price{}, err
+		}
+	}
+	if !named || field("BRAND_ID") != "" {
+		if err := integer("BRAND_ID", &p.BrandID); err != nil {
+			return Price{}, err
+		}
+	}
+	if err := date("START_DATE", &p.StartDate); err != nil {
+		return Price{}, err
+	}
+	if err := date("END_DATE", &p.EndDate); err != nil {
+		return Price{}, err
+	}
+	if err := integer("PRODUCT_ID", &p.ProductID); err != nil {
+		return Price{}, err
+	}
+	if err := integer("PRIORITY", &p.Priority); err != nil {
+		return Price{}, err
+	}
+
+	if _, err := LookupCurrency(field("CURR")); err != nil {
+		return Price{}, &ValidationError{Field: "CURR", Reason: err.Error()}
+	}
+
+	money, err := ParseMoney(field("PRICE"), field("CURR"))
+	if err != nil {
+		return Price{}, &ValidationError{Field: "PRICE", Reason: err.Error()}
+	}
+	p.Price = money
+
+	return p, nil
+}
+
+// WritePricesCSV writes prices as a price list CSV file with a header row in
+// the layout read by ReadPricesCSV.
+func WritePricesCSV(w io.Writer, prices []Price) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, p := range prices {
+		err := cw.Write([]string{
+			strconv.Itoa(p.BrandID),
+			p.StartDate.UTC().Format(CSVTimeLayout),
+			p.EndDate.UTC().Format(CSVTimeLayout),
+			strconv.Itoa(p.ID),
+			strconv.Itoa(p.ProductID),
+			strconv.Itoa(p.Priority),
+			p.Price.Decimal(),
+			p.Price.Currency,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return nil
+}
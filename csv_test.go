@@ -0,0 +1,137 @@
+package pricing_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karlskewes/pricing"
+)
+
+func TestPricesCSV_RoundTrip(t *testing.T) {
+	want, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		want[i].ID = i + 1
+	}
+
+	var buf bytes.Buffer
+	if err := pricing.WritePricesCSV(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCSV := `BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR
+1,2020-06-14-00.00.00,2020-12-31-23.59.59,1,35455,0,35.50,EUR
+1,2020-06-14-15.00.00,2020-06-14-18.30.00,2,35455,1,25.45,EUR
+1,2020-06-15-00.00.00,2020-06-15-11.00.00,3,35455,1,30.50,EUR
+1,2020-06-15-16.00.00,2020-12-31-23.59.59,4,35455,1,38.95,EUR
+`
+	if diff := cmp.Diff(wantCSV, buf.String()); diff != "" {
+		t.Errorf("WritePricesCSV(...) mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := pricing.ReadPricesCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadPricesCSV(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadPricesCSV(t *testing.T) {
+	testCases := map[string]struct {
+		input     string
+		want      []pricing.Price
+		wantLines map[int]string // wantLines[line]column of each invalid row
+		wantErr   bool
+	}{
+		"columns reordered without PRICE_LIST": {
+			input: "CURR,PRICE,PRIORITY,PRODUCT_ID,END_DATE,START_DATE,BRAND_ID\n" +
+				"JPY,3550,0,35455,2020-12-31-23.59.59,2020-06-14-00.00.00,1\n",
+			want: []pricing.Price{{
+				BrandID:   1,
+				StartDate: mustParseCSVTime(t, "2020-06-14-00.00.00"),
+				EndDate:   mustParseCSVTime(t, "2020-12-31-23.59.59"),
+				ProductID: 35455,
+				Price:     pricing.Money{Amount: 3550, Currency: "JPY"},
+			}},
+		},
+		"invalid rows": {
+			input: "BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n" +
+				"1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n" +
+				"one,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n" +
+				"1,2020-06-14T00:00:00Z,2020-12-31-23.59.59,,35455,0,35.50,EUR\n" +
+				"1,2020-12-31-23.59.59,2020-06-14-00.00.00,,35455,0,35.50,EUR\n" +
+				"1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.505,EUR\n" +
+				"1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EURO\n",
+			wantLines: map[int]string{3: "BRAND_ID", 4: "START_DATE", 5: "END_DATE", 6: "PRICE", 7: "CURR"},
+		},
+		"missing column": {
+			input:   "BRAND_ID,START_DATE,END_DATE,PRODUCT_ID,PRIORITY,PRICE\n",
+			wantErr: true,
+		},
+		"empty": {
+			input:   "",
+			wantErr: true,
+		},
+		"wrong number of fields": {
+			input: "BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n" +
+				"1,2020-06-14-00.00.00\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := pricing.ReadPricesCSV(strings.NewReader(tt.input))
+
+			var be *pricing.BatchError
+			switch {
+			case tt.wantLines != nil:
+				if !errors.As(err, &be) {
+					t.Fatalf("want *BatchError - got: %v", err)
+				}
+				gotLines := map[int]string{}
+				for _, row := range be.Rows {
+					var ve *pricing.ValidationError
+					if errors.As(row.Err, &ve) {
+						gotLines[row.Line] = ve.Field
+					}
+				}
+				if diff := cmp.Diff(tt.wantLines, gotLines); diff != "" {
+					t.Errorf("invalid lines mismatch (-want +got):\n%s", diff)
+				}
+			case tt.wantErr:
+				if !errors.Is(err, pricing.ErrInvalidArgument) {
+					t.Errorf("want ErrInvalidArgument - got: %v", err)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("ReadPricesCSV(...) mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func mustParseCSVTime(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	date, err := time.Parse(pricing.CSVTimeLayout, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return date
+}
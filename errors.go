@@ -0,0 +1,164 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinel errors returned by every Repository, and therefore the Service,
+// wrapped with details. Use errors.Is to check for them.
+var (
+	// ErrNotFound is returned when the requested brand, price or promotion
+	// doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write clashes with existing data, e.g: a
+	// duplicate brand name.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidArgument is returned when the provided data can't be stored.
+	// *ValidationError matches it too.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrUnavailable is returned when the backend can't be reached, e.g: a
+	// database outage. Retrying later may succeed.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// ValidationError describes a field of a Price or Brand that is invalid and
+// can't be stored.
+type ValidationError struct {
+	Field  string // Field name as exposed by the API, e.g: brand_id.
+	Reason string // Human readable reason, e.g: must be greater than 0.
+}
+
+func (ve *ValidationError) Error() string {
+	return ve.Field + ": " + ve.Reason
+}
+
+// Is reports ValidationError as an ErrInvalidArgument for errors.Is.
+func (ve *ValidationError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
+
+// errBrandNotFound is the error Repositories return when a brand name doesn't
+// exist so the message is identical regardless of implementation.
+func errBrandNotFound(name string) error {
+	return fmt.Errorf("%w: no brand with name: %s", ErrNotFound, name)
+}
+
+// errPriceNotFound is the error Repositories return when no price applies so
+// the message is identical regardless of implementation.
+func errPriceNotFound(brandID, productID int, date time.Time) error {
+	return fmt.Errorf("%w: no price for brand_id: %d, product_id: %d at: %s", ErrNotFound, brandID, productID, date.Format(time.RFC3339))
+}
+
+// errNoPriorPrice is the error Service returns when no price applied during
+// the window before a date.
+func errNoPriorPrice(brandID, productID int, from, to time.Time) error {
+	return fmt.Errorf("%w: no price for brand_id: %d, product_id: %d between: %s and: %s", ErrNotFound, brandID, productID, from.Format(time.RFC3339), to.Format(time.RFC3339))
+}
+
+// errBrandExists is the error Repositories return when a brand name is
+// already taken.
+func errBrandExists(name string) error {
+	return fmt.Errorf("%w: brand name already exists: %s", ErrConflict, name)
+}
+
+// errBrandIDNotFound is the error Repositories return when a brand ID doesn't
+// exist.
+func errBrandIDNotFound(id int) error {
+	return fmt.Errorf("%w: no brand with id: %d", ErrNotFound, id)
+}
+
+// errPriceIDNotFound is the error Repositories return when a price ID doesn't
+// exist.
+func errPriceIDNotFound(id int) error {
+	return fmt.Errorf("%w: no price with id: %d", ErrNotFound, id)
+}
+
+// errBrandHasPrices is the error Repositories return when deleting a brand
+// that prices still reference.
+func errBrandHasPrices(id int) error {
+	return fmt.Errorf("%w: brand id: %d is referenced by prices, delete them first", ErrConflict, id)
+}
+
+// errPromotionIDNotFound is the error Repositories return when a promotion ID
+// doesn't exist.
+func errPromotionIDNotFound(id int) error {
+	return fmt.Errorf("%w: no promotion with id: %d", ErrNotFound, id)
+}
+
+// errBrandHasPromotions is the error Repositories return when deleting a brand
+// that promotions still reference.
+func errBrandHasPromotions(id int) error {
+	return fmt.Errorf("%w: brand id: %d is referenced by promotions, delete them first", ErrConflict, id)
+}
+
+// errBrandDoesNotExist is the error Repositories return when a price refers to
+// a brand that doesn't exist.
+func errBrandDoesNotExist() error {
+	return &ValidationError{Field: "brand_id", Reason: "brand does not exist"}
+}
+
+// PriceConflictError is returned when a price overlaps prices of the same
+// brand, product and priority, making which price applies ambiguous.
+type PriceConflictError struct {
+	IDs []int // IDs of the clashing prices, may be empty if they were deleted concurrently
+}
+
+func (pce *PriceConflictError) Error() string {
+	return fmt.Sprintf("%s: price overlaps prices with the same priority, ids: %v", ErrConflict, pce.IDs)
+}
+
+// Is reports PriceConflictError as an ErrConflict for errors.Is.
+func (pce *PriceConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// RowError is the error of a single row of a batch write.
+type RowError struct {
+	Index int // Zero based index of the row in the batch.
+	Line  int // Line number of the row in its source file, 0 if not read from a file.
+	Err   error
+}
+
+func (re *RowError) Error() string {
+	if re.Line > 0 {
+		return fmt.Sprintf("line %d: %v", re.Line, re.Err)
+	}
+
+	return fmt.Sprintf("row %d: %v", re.Index, re.Err)
+}
+
+func (re *RowError) Unwrap() error {
+	return re.Err
+}
+
+// BatchError is returned when rows of a batch write are invalid or conflict,
+// none of the batch is stored. errors.Is and errors.As match the errors of any
+// row.
+type BatchError struct {
+	Rows []RowError // Sorted by Index.
+}
+
+func (be *BatchError) Error() string {
+	if len(be.Rows) == 0 {
+		return "batch failed"
+	}
+
+	return fmt.Sprintf("%d batch rows failed, first: %v", len(be.Rows), &be.Rows[0])
+}
+
+func (be *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(be.Rows))
+	for i := range be.Rows {
+		errs = append(errs, &be.Rows[i])
+	}
+
+	return errs
+}
+
+// errBatchRowsOverlap is the row error of a batch price overlapping other
+// rows of the batch with the same priority.
+func errBatchRowsOverlap(rows []int) error {
+	return fmt.Errorf("%w: price overlaps rows %v of the batch with the same priority", ErrConflict, rows)
+}
@@ -0,0 +1,55 @@
+package pricing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// readyTimeout bounds how long a readiness check waits for the repository.
+const readyTimeout = 2 * time.Second
+
+// HealthResponse is the body of successful liveness and readiness checks.
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// healthHandler serves the liveness and readiness endpoints for orchestrators
+// and load balancers.
+type healthHandler struct {
+	svc *Service
+	// draining is set while the App waits for requests in transit before
+	// shutting down, so load balancers stop routing new requests.
+	draining atomic.Bool
+}
+
+// RegisterRoutes registers the health endpoints with mux.
+func (hh *healthHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", hh.Healthz)
+	mux.HandleFunc("GET /readyz", hh.Readyz)
+}
+
+// Healthz responds OK while the process is able to serve HTTP requests.
+func (hh *healthHandler) Healthz(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, req, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz responds OK if the repository can serve requests and the App isn't
+// shutting down, otherwise 503 Service Unavailable.
+func (hh *healthHandler) Readyz(w http.ResponseWriter, req *http.Request) {
+	if hh.draining.Load() {
+		writeProblem(w, req, http.StatusServiceUnavailable, CodeUnavailable, "", "server is shutting down")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), readyTimeout)
+	defer cancel()
+
+	if err := hh.svc.Ping(ctx); err != nil {
+		writeProblem(w, req, http.StatusServiceUnavailable, CodeUnavailable, "", "repository not ready")
+		return
+	}
+
+	writeJSON(w, req, http.StatusOK, HealthResponse{Status: "ok"})
+}
@@ -0,0 +1,490 @@
+package pricing
+
+import (
+	"sort"
+	"time"
+)
+
+// productKey identifies the prices of a brand's product.
+type productKey struct {
+	brandID   int
+	productID int
+}
+
+// segment is a time range, inclusive of start and end like Price, during which
+// the same set of prices apply.
+type segment struct {
+	start time.Time
+	end   time.Time
+	// candidates are the highest priority applicable prices, highest first,
+	// at most maxCandidates: the winner and the price it's compared at.
+	candidates []Price
+
+	// effectiveStart and effectiveEnd span the contiguous segments either
+	// side with the same winning price, i.e: how long candidates[0] applies.
+	effectiveStart time.Time
+	effectiveEnd   time.Time
+}
+
+// maxCandidates bounds the candidates kept per segment so memory doesn't grow
+// with the number of overlapping prices.
+const maxCandidates = 2
+
+// priceIndex holds the prices of a single brand's product flattened into
+// sorted, non-overlapping segments so the price applying at an instant can be
+// found with a binary search instead of scanning every price.
+// A priceIndex is immutable once built, add prices by building a new one.
+type priceIndex struct {
+	prices   []Price   // sorted by StartDate
+	segments []segment // sorted by start, gaps without any price are omitted
+}
+
+// newPriceIndex builds an index of the provided prices which must all have the
+// same brand and product. The prices slice is not retained.
+func newPriceIndex(prices []Price) *priceIndex {
+	sorted := make([]Price, len(prices))
+	copy(sorted, prices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartDate.Before(sorted[j].StartDate)
+	})
+
+	return &priceIndex{
+		prices:   sorted,
+		segments: buildSegments(sorted),
+	}
+}
+
+// with returns a new index containing the existing prices and price. Only the
+// segments price overlaps are split or gain it as a candidate, and effective
+// windows are only reset for the runs around them. Copying the prices and
+// segments into the new index is still O(n), but without sorting.
+func (pi *priceIndex) with(price Price) *priceIndex {
+	if pi == nil {
+		return newPriceIndex([]Price{price})
+	}
+
+	// after prices starting at the same time, like newPriceIndex's stable sort
+	i := sort.Search(len(pi.prices), func(i int) bool {
+		return pi.prices[i].StartDate.After(price.StartDate)
+	})
+	prices := make([]Price, 0, len(pi.prices)+1)
+	prices = append(prices, pi.prices[:i]...)
+	prices = append(prices, price)
+	prices = append(prices, pi.prices[i:]...)
+
+	// segments[lo:hi] overlap price
+	lo := sort.Search(len(pi.segments), func(i int) bool {
+		return !pi.segments[i].end.Before(price.StartDate)
+	})
+	hi := sort.Search(len(pi.segments), func(i int) bool {
+		return pi.segments[i].start.After(price.EndDate)
+	})
+
+	var middle []segment
+	cursor := price.StartDate // start of the range not yet covered
+	for _, seg := range pi.segments[lo:hi] {
+		if seg.start.Before(cursor) {
+			middle = append(middle, segment{start: seg.start, end: cursor.Add(-time.Nanosecond), candidates: seg.candidates})
+		}
+		if seg.start.After(cursor) {
+			middle = append(middle, segment{start: cursor, end: seg.start.Add(-time.Nanosecond), candidates: []Price{price}})
+			cursor = seg.start
+		}
+
+		end := seg.end
+		if end.After(price.EndDate) {
+			end = price.EndDate
+		}
+		middle = append(middle, segment{start: cursor, end: end, candidates: withCandidate(seg.candidates, price)})
+
+		if seg.end.After(price.EndDate) {
+			middle = append(middle, segment{start: price.EndDate.Add(time.Nanosecond), end: seg.end, candidates: seg.candidates})
+		}
+		cursor = end.Add(time.Nanosecond)
+	}
+	if !cursor.After(price.EndDate) {
+		middle = append(middle, segment{start: cursor, end: price.EndDate, candidates: []Price{price}})
+	}
+
+	segments := make([]segment, 0, len(pi.segments)-(hi-lo)+len(middle))
+	segments = append(segments, pi.segments[:lo]...)
+	segments = append(segments, middle...)
+	segments = append(segments, pi.segments[hi:]...)
+
+	// the runs either side of middle may now extend into it or end sooner
+	first, last := lo, lo+len(middle)-1
+	if first > 0 {
+		runStart := segments[first-1].effectiveStart
+		first = sort.Search(first, func(i int) bool { return !segments[i].start.Before(runStart) })
+	}
+	if last+1 < len(segments) {
+		runEnd := segments[last+1].effectiveEnd
+		last += sort.Search(len(segments)-last-1, func(i int) bool { return !segments[last+1+i].end.Before(runEnd) }) + 1
+	}
+	setEffectiveWindows(segments[first : last+1])
+
+	return &priceIndex{prices: prices, segments: segments}
+}
+
+// withCandidate returns a copy of candidates including price, keeping at most
+// maxCandidates. The dropped candidates can never win or be compared at.
+func withCandidate(candidates []Price, price Price) []Price {
+	merged := make([]Price, 0, len(candidates)+1)
+	merged = append(merged, candidates...)
+	merged = append(merged, price)
+	sortCandidates(merged)
+
+	if len(merged) > maxCandidates {
+		merged = merged[:maxCandidates]
+	}
+
+	return merged
+}
+
+// without returns a new index containing the existing prices except the one
+// with id, or nil if no prices remain. It's rebuilt from the prices in
+// O(n log n) as segments don't keep the candidates that may replace it.
+func (pi *priceIndex) without(id int) *priceIndex {
+	if pi == nil {
+		return nil
+	}
+
+	prices := make([]Price, 0, len(pi.prices))
+	for _, p := range pi.prices {
+		if p.ID != id {
+			prices = append(prices, p)
+		}
+	}
+
+	if len(prices) == 0 {
+		return nil
+	}
+
+	return newPriceIndex(prices)
+}
+
+// conflicts returns the IDs of prices, other than price itself, with the same
+// priority whose date range overlaps price's.
+func (pi *priceIndex) conflicts(price Price) []int {
+	if pi == nil {
+		return nil
+	}
+
+	var ids []int
+	for _, p := range pi.prices {
+		if p.StartDate.After(price.EndDate) {
+			break // sorted by StartDate so no later prices overlap
+		}
+
+		if p.ID == price.ID || p.Priority != price.Priority || p.EndDate.Before(price.StartDate) {
+			continue
+		}
+
+		ids = append(ids, p.ID)
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+// samePriorityOverlaps returns the IDs of the prices each price overlaps with
+// the same priority, omitting prices without overlaps. Prices must be sorted
+// by StartDate and belong to a single brand's product.
+// Sweeps the prices once so it's O(n) when few prices overlap.
+func samePriorityOverlaps(prices []Price) map[int][]int {
+	overlaps := map[int][]int{}
+	active := map[int][]Price{} // active[priority] prices that haven't ended yet
+
+	for _, p := range prices {
+		kept := active[p.Priority][:0]
+		for _, a := range active[p.Priority] {
+			if a.EndDate.Before(p.StartDate) {
+				continue
+			}
+			kept = append(kept, a)
+			overlaps[p.ID] = append(overlaps[p.ID], a.ID)
+			overlaps[a.ID] = append(overlaps[a.ID], p.ID)
+		}
+		active[p.Priority] = append(kept, p)
+	}
+
+	return overlaps
+}
+
+// batchConflicts returns a RowError for each price in batch overlapping the
+// existing prices of its brand's product, or other rows of the batch, with the
+// same priority. The batch prices don't need IDs.
+func batchConflicts(existing map[productKey][]Price, batch []Price) []RowError {
+	added := map[productKey][]Price{}
+	for i, p := range batch {
+		p.ID = -(i + 1) // identifies the row without clashing with existing IDs
+		key := productKey{brandID: p.BrandID, productID: p.ProductID}
+		added[key] = append(added[key], p)
+	}
+
+	var rowErrs []RowError
+	for key, rows := range added {
+		prices := make([]Price, 0, len(existing[key])+len(rows))
+		prices = append(prices, existing[key]...)
+		prices = append(prices, rows...)
+		sort.SliceStable(prices, func(i, j int) bool {
+			return prices[i].StartDate.Before(prices[j].StartDate)
+		})
+
+		for id, others := range samePriorityOverlaps(prices) {
+			if id > 0 {
+				continue // existing prices never overlap each other
+			}
+
+			var ids, otherRows []int
+			for _, other := range others {
+				if other > 0 {
+					ids = append(ids, other)
+				} else {
+					otherRows = append(otherRows, -other-1)
+				}
+			}
+			sort.Ints(ids)
+			sort.Ints(otherRows)
+
+			var err error = &PriceConflictError{IDs: ids}
+			if len(ids) == 0 {
+				err = errBatchRowsOverlap(otherRows)
+			}
+			rowErrs = append(rowErrs, RowError{Index: -id - 1, Err: err})
+		}
+	}
+
+	sort.Slice(rowErrs, func(i, j int) bool { return rowErrs[i].Index < rowErrs[j].Index })
+
+	return rowErrs
+}
+
+// batchRowErrors returns a RowError for each price in batch whose brand
+// doesn't exist, or that conflicts like batchConflicts, sorted by index so
+// every failed row is reported at once.
+func batchRowErrors(brandExists func(id int) bool, existing map[productKey][]Price, batch []Price) []RowError {
+	var rowErrs []RowError
+	failed := map[int]bool{}
+	for i, p := range batch {
+		if !brandExists(p.BrandID) {
+			rowErrs = append(rowErrs, RowError{Index: i, Err: errBrandDoesNotExist()})
+			failed[i] = true
+		}
+	}
+
+	for _, rowErr := range batchConflicts(existing, batch) {
+		if !failed[rowErr.Index] {
+			rowErrs = append(rowErrs, rowErr)
+		}
+	}
+
+	sort.Slice(rowErrs, func(i, j int) bool { return rowErrs[i].Index < rowErrs[j].Index })
+
+	return rowErrs
+}
+
+// find returns the price with id in O(n).
+func (pi *priceIndex) find(id int) (Price, bool) {
+	if pi == nil {
+		return Price{}, false
+	}
+
+	for _, p := range pi.prices {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return Price{}, false
+}
+
+// lookup returns the segment containing date in O(log n).
+func (pi *priceIndex) lookup(date time.Time) (segment, bool) {
+	if pi == nil {
+		return segment{}, false
+	}
+
+	// first segment that hasn't ended before date
+	i := sort.Search(len(pi.segments), func(i int) bool {
+		return !pi.segments[i].end.Before(date)
+	})
+	if i == len(pi.segments) || pi.segments[i].start.After(date) {
+		return segment{}, false
+	}
+
+	return pi.segments[i], true
+}
+
+// timeline returns the prices applying between from and to, inclusive, as
+// contiguous segments with gaps filled by segments without a price.
+func (pi *priceIndex) timeline(from, to time.Time) []PriceSegment {
+	var timeline []PriceSegment
+	cursor := from // start of the next segment to emit
+
+	var segments []segment
+	if pi != nil {
+		// first segment that hasn't ended before from
+		i := sort.Search(len(pi.segments), func(i int) bool {
+			return !pi.segments[i].end.Before(from)
+		})
+		segments = pi.segments[i:]
+	}
+
+	for _, seg := range segments {
+		if seg.start.After(to) || cursor.After(to) {
+			break
+		}
+
+		// segments of the same run were merged into an earlier timeline segment
+		if seg.effectiveEnd.Before(cursor) {
+			continue
+		}
+
+		start := seg.effectiveStart
+		if start.Before(cursor) {
+			start = cursor
+		}
+
+		if start.After(cursor) {
+			timeline = append(timeline, PriceSegment{StartDate: cursor, EndDate: start.Add(-time.Nanosecond)})
+		}
+
+		end := seg.effectiveEnd
+		if end.After(to) {
+			end = to
+		}
+
+		timeline = append(timeline, PriceSegment{
+			StartDate: start,
+			EndDate:   end,
+			PriceID:   seg.candidates[0].ID,
+			Price:     seg.candidates[0].Price,
+		})
+
+		cursor = end.Add(time.Nanosecond)
+	}
+
+	if !cursor.After(to) {
+		timeline = append(timeline, PriceSegment{StartDate: cursor, EndDate: to})
+	}
+
+	return timeline
+}
+
+// buildSegments sweeps the prices, sorted by StartDate, splitting time at every
+// price start and end so each segment has a fixed set of candidates.
+// Price ranges are inclusive so a price stops applying 1ns after its EndDate.
+func buildSegments(prices []Price) []segment {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	boundaries := make([]time.Time, 0, len(prices)*2)
+	for _, p := range prices {
+		boundaries = append(boundaries, p.StartDate, p.EndDate.Add(time.Nanosecond))
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	segments := make([]segment, 0, len(boundaries))
+	active := make([]Price, 0)
+	next := 0 // next price in prices to become active
+
+	for i := 0; i < len(boundaries)-1; i++ {
+		start, stop := boundaries[i], boundaries[i+1]
+		if !start.Before(stop) {
+			continue // duplicate boundary
+		}
+
+		// drop prices that ended before this segment
+		kept := active[:0]
+		for _, p := range active {
+			if p.EndDate.Before(start) {
+				continue
+			}
+			kept = append(kept, p)
+		}
+		active = kept
+
+		for next < len(prices) && !prices[next].StartDate.After(start) {
+			active = append(active, prices[next])
+			next++
+		}
+
+		if len(active) == 0 {
+			continue
+		}
+
+		// active is unordered so sorting it in place is safe
+		sortCandidates(active)
+		candidates := make([]Price, min(len(active), maxCandidates))
+		copy(candidates, active)
+
+		segments = append(segments, segment{
+			start:      start,
+			end:        stop.Add(-time.Nanosecond),
+			candidates: candidates,
+		})
+	}
+
+	setEffectiveWindows(segments)
+
+	return segments
+}
+
+// setEffectiveWindows sets the effective window of each segment to the run of
+// contiguous segments sharing its winning price. Segments split where a lower
+// priority price starts or ends still belong to the same run.
+func setEffectiveWindows(segments []segment) {
+	for runStart := 0; runStart < len(segments); {
+		winner := segments[runStart].candidates[0].ID
+
+		runEnd := runStart
+		for runEnd+1 < len(segments) &&
+			segments[runEnd+1].candidates[0].ID == winner &&
+			segments[runEnd+1].start.Equal(segments[runEnd].end.Add(time.Nanosecond)) {
+			runEnd++
+		}
+
+		for i := runStart; i <= runEnd; i++ {
+			segments[i].effectiveStart = segments[runStart].start
+			segments[i].effectiveEnd = segments[runEnd].end
+		}
+
+		runStart = runEnd + 1
+	}
+}
+
+// finalPrice returns the price applying during seg, compared at the next
+// highest priority candidate if any.
+func (seg segment) finalPrice() FinalPrice {
+	pvp := seg.candidates[0] // highest priority
+
+	fp := FinalPrice{
+		ID:                 pvp.ID,
+		BrandID:            pvp.BrandID,
+		StartDate:          pvp.StartDate,
+		EndDate:            pvp.EndDate,
+		EffectiveStartDate: seg.effectiveStart,
+		EffectiveEndDate:   seg.effectiveEnd,
+		ProductID:          pvp.ProductID,
+		Price:              pvp.Price,
+	}
+	if len(seg.candidates) > 1 {
+		fp.CompareAtPriceID = seg.candidates[1].ID
+		fp.CompareAtPrice = seg.candidates[1].Price
+	}
+
+	return fp
+}
+
+// sortCandidates orders prices by the highest priority first. Equal
+// priorities are ordered by ID so the earliest added price wins.
+func sortCandidates(prices []Price) {
+	sort.SliceStable(prices, func(i, j int) bool {
+		if prices[i].Priority != prices[j].Priority {
+			return prices[i].Priority > prices[j].Priority
+		}
+		return prices[i].ID < prices[j].ID
+	})
+}
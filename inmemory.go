@@ -2,54 +2,158 @@ package pricing
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Verify interface compliance at compile time
 var _ Repository = (*InMemoryRepository)(nil)
 
+// InMemoryRepository stores pricing data in memory. Reads load the current
+// immutable snapshot via an atomic pointer and never block, writers are
+// serialised and publish a new snapshot containing their changes.
+// The zero value is an empty repository ready for use.
 type InMemoryRepository struct {
-	brands map[string]int // brands[name]ID
-	prices []Price        // suboptimal data structure
-	mu     sync.RWMutex
-	// logger zerolog.Logger // log db queries, etc
+	snap atomic.Pointer[snapshot]
+	mu   sync.Mutex // serialises writers so no write is lost between load and store
+	// logger logs published snapshots, slog.Default if nil for the zero value.
+	logger *slog.Logger
 }
 
-// NewInMemoryRepository returns a memory backed Repository for persisting pricing data.
-func NewInMemoryRepository(ctx context.Context) (*InMemoryRepository, error) {
-	brands := make(map[string]int)
-	prices := make([]Price, 0)
-	return &InMemoryRepository{
-		brands: brands,
-		prices: prices,
-	}, nil
+// snapshot is an immutable view of the repository at a point in time. It must
+// not be modified once published, writers clone it via update instead.
+// The maps are sharded so a writer only clones the shards it modifies.
+type snapshot struct {
+	brands     *shardedMap[string, int]             // brands[name]ID
+	brandNames *shardedMap[int, string]             // brandNames[ID]name
+	prices     *shardedMap[productKey, *priceIndex] // prices bucketed by brand & product, indexed by time
+	priceKeys  *shardedMap[int, productKey]         // priceKeys[ID] bucket of the price with ID
+	promotions *shardedMap[int, Promotion]          // promotions[ID]
+	// productPromotions lists the IDs of the promotions including each
+	// brand's product in ascending order. Replace rather than append to a
+	// slice, published snapshots share them.
+	productPromotions *shardedMap[productKey, []int]
+	// generated IDs start from 1 to match Postgres implementation
+	lastBrandID     int
+	lastPriceID     int
+	lastPromotionID int
 }
 
-func (imr *InMemoryRepository) Shutdown(ctx context.Context) error {
-	// NO-OP
-	return nil
+var emptySnapshot = &snapshot{
+	brands:            newShardedMap[string, int](shardString),
+	brandNames:        newShardedMap[int, string](shardInt),
+	prices:            newShardedMap[productKey, *priceIndex](shardProductKey),
+	priceKeys:         newShardedMap[int, productKey](shardInt),
+	promotions:        newShardedMap[int, Promotion](shardInt),
+	productPromotions: newShardedMap[productKey, []int](shardProductKey),
+}
+
+// NewInMemoryRepository returns a memory backed Repository for persisting pricing data.
+func NewInMemoryRepository(ctx context.Context, opts ...RepositoryOption) (*InMemoryRepository, error) {
+	imr := &InMemoryRepository{logger: newRepositoryOptions(opts).logger}
+	imr.snap.Store(emptySnapshot)
+
+	return imr, nil
 }
 
-func (imr *InMemoryRepository) AddBrand(ctx context.Context, name string) error {
-	if id, ok := imr.brands[name]; ok {
-		return fmt.Errorf("brand name already exists: %s with id: %d", name, id)
+// load returns the current snapshot for reading.
+func (imr *InMemoryRepository) load() *snapshot {
+	if snap := imr.snap.Load(); snap != nil {
+		return snap
 	}
 
+	return emptySnapshot
+}
+
+// update applies fn to a fork of the current snapshot and publishes it if fn
+// succeeds, so readers see all of fn's changes or none of them. A fork only
+// clones the shards fn writes to, once each however many changes fn makes.
+// Values are copied shallowly, fn must replace rather than modify a
+// *priceIndex. Nothing is published if ctx is done before fn completes.
+func (imr *InMemoryRepository) update(ctx context.Context, fn func(next *snapshot) error) error {
 	imr.mu.Lock()
 	defer imr.mu.Unlock()
 
-	imr.brands[name] = len(imr.brands) + 1 // start from 1 to match Postgres implementation
+	// ctx may have expired waiting for other writers.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to update in-memory snapshot: %w", err)
+	}
+
+	cur := imr.load()
+	next := &snapshot{
+		brands:            cur.brands.fork(),
+		brandNames:        cur.brandNames.fork(),
+		prices:            cur.prices.fork(),
+		priceKeys:         cur.priceKeys.fork(),
+		promotions:        cur.promotions.fork(),
+		productPromotions: cur.productPromotions.fork(),
+		lastBrandID:       cur.lastBrandID,
+		lastPriceID:       cur.lastPriceID,
+		lastPromotionID:   cur.lastPromotionID,
+	}
+
+	if err := fn(next); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to update in-memory snapshot: %w", err)
+	}
+
+	imr.snap.Store(next)
 
+	logger := imr.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Debug("in-memory snapshot published", slog.Int("brands", next.brands.len()), slog.Int("prices", next.priceKeys.len()), slog.Int("promotions", next.promotions.len()))
+
+	return nil
+}
+
+// Ping always succeeds, the in-memory repository is ready once created.
+func (imr *InMemoryRepository) Ping(ctx context.Context) error {
 	return nil
 }
 
+func (imr *InMemoryRepository) Shutdown(ctx context.Context) error {
+	// NO-OP
+	return nil
+}
+
+func (imr *InMemoryRepository) AddBrand(ctx context.Context, name string) (Brand, error) {
+	var brand Brand
+
+	err := imr.update(ctx, func(next *snapshot) error {
+		if _, ok := next.brands.get(name); ok {
+			return errBrandExists(name)
+		}
+
+		next.lastBrandID++
+		brand = Brand{
+			ID:   next.lastBrandID,
+			Name: name,
+		}
+		next.brands.set(name, brand.ID)
+		next.brandNames.set(brand.ID, name)
+
+		return nil
+	})
+	if err != nil {
+		return Brand{}, err
+	}
+
+	return brand, nil
+}
+
 func (imr *InMemoryRepository) GetBrand(ctx context.Context, name string) (Brand, error) {
-	id, ok := imr.brands[name]
+	id, ok := imr.load().brands.get(name)
 	if !ok {
-		return Brand{}, fmt.Errorf("brand doesn't exist in repository: %s", name)
+		return Brand{}, errBrandNotFound(name)
 	}
 
 	brand := Brand{
@@ -60,53 +164,444 @@ func (imr *InMemoryRepository) GetBrand(ctx context.Context, name string) (Brand
 	return brand, nil
 }
 
-func (imr *InMemoryRepository) AddPrice(ctx context.Context, price Price) error {
-	imr.mu.Lock()
-	defer imr.mu.Unlock()
-	imr.prices = append(imr.prices, price)
+func (imr *InMemoryRepository) GetBrandByID(ctx context.Context, id int) (Brand, error) {
+	name, ok := imr.load().brandNames.get(id)
+	if !ok {
+		return Brand{}, errBrandIDNotFound(id)
+	}
 
-	return nil
+	return Brand{ID: id, Name: name}, nil
 }
 
-func (imr *InMemoryRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
-	// initialize a slice of applicable rates which can filter later
-	rates := make([]Price, 0)
-
-	imr.mu.RLock()
-	defer imr.mu.RUnlock()
-	// O(n) walk slice to find suitable items
-	for _, price := range imr.prices {
-		if price.BrandID != brandID || price.ProductID != productID {
-			continue
+func (imr *InMemoryRepository) AddPrice(ctx context.Context, price Price) (Price, error) {
+	err := imr.update(ctx, func(next *snapshot) error {
+		if _, ok := next.brandNames.get(price.BrandID); !ok {
+			return errBrandDoesNotExist()
 		}
-		if price.StartDate.After(date) && !price.StartDate.Equal(date) {
-			continue
+
+		next.lastPriceID++
+		price.ID = next.lastPriceID
+
+		if err := next.checkConflicts(price); err != nil {
+			return err
 		}
-		if price.EndDate.Before(date) && !price.EndDate.Equal(date) {
-			continue
+
+		next.addPrice(price)
+
+		return nil
+	})
+	if err != nil {
+		return Price{}, err
+	}
+
+	return price, nil
+}
+
+// AddPrices stores every price, with generated IDs, in a single snapshot swap
+// or none of them if any row fails.
+func (imr *InMemoryRepository) AddPrices(ctx context.Context, prices []Price) ([]Price, error) {
+	added := make([]Price, len(prices))
+	copy(added, prices)
+
+	err := imr.update(ctx, func(next *snapshot) error {
+		existing := map[productKey][]Price{}
+		for _, price := range added {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("failed to add prices: %w", err)
+			}
+
+			key := productKey{brandID: price.BrandID, productID: price.ProductID}
+			if pi, ok := next.prices.get(key); ok {
+				existing[key] = pi.prices
+			}
+		}
+
+		brandExists := func(id int) bool {
+			_, ok := next.brandNames.get(id)
+			return ok
+		}
+		if rowErrs := batchRowErrors(brandExists, existing, added); len(rowErrs) > 0 {
+			return &BatchError{Rows: rowErrs}
+		}
+
+		byKey := map[productKey][]Price{}
+		for i := range added {
+			next.lastPriceID++
+			added[i].ID = next.lastPriceID
+
+			key := productKey{brandID: added[i].BrandID, productID: added[i].ProductID}
+			byKey[key] = append(byKey[key], added[i])
+			next.priceKeys.set(added[i].ID, key)
+		}
+
+		// build each index once rather than once per price
+		for key, rows := range byKey {
+			merged := make([]Price, 0, len(existing[key])+len(rows))
+			merged = append(merged, existing[key]...)
+			next.prices.set(key, newPriceIndex(append(merged, rows...)))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// GetPriceByID returns the price with id.
+func (imr *InMemoryRepository) GetPriceByID(ctx context.Context, id int) (Price, error) {
+	snap := imr.load()
+
+	key, _ := snap.priceKeys.get(id)
+	pi, _ := snap.prices.get(key)
+	price, ok := pi.find(id)
+	if !ok {
+		return Price{}, errPriceIDNotFound(id)
+	}
+
+	return price, nil
+}
+
+// ListPrices returns the prices matching filter ordered by ID.
+func (imr *InMemoryRepository) ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error) {
+	snap := imr.load()
+
+	prices := make([]Price, 0)
+	var err error
+	snap.prices.all(func(key productKey, pi *priceIndex) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		if (filter.BrandID != 0 && key.brandID != filter.BrandID) || (filter.ProductID != 0 && key.productID != filter.ProductID) {
+			return true
+		}
+		prices = append(prices, pi.prices...)
+
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prices: %w", err)
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].ID < prices[j].ID })
+
+	return prices, nil
+}
+
+// UpdatePrice replaces the price with the same ID.
+func (imr *InMemoryRepository) UpdatePrice(ctx context.Context, price Price) (Price, error) {
+	err := imr.update(ctx, func(next *snapshot) error {
+		if _, ok := next.priceKeys.get(price.ID); !ok {
+			return errPriceIDNotFound(price.ID)
+		}
+
+		return next.replacePrice(price)
+	})
+	if err != nil {
+		return Price{}, err
+	}
+
+	return price, nil
+}
+
+// PatchPrice replaces the price with id by patch of it. patch runs under the
+// writer lock so no other write can change the price in between.
+func (imr *InMemoryRepository) PatchPrice(ctx context.Context, id int, patch func(Price) (Price, error)) (Price, error) {
+	var price Price
+	err := imr.update(ctx, func(next *snapshot) error {
+		key, ok := next.priceKeys.get(id)
+		if !ok {
+			return errPriceIDNotFound(id)
+		}
+		pi, _ := next.prices.get(key)
+		current, _ := pi.find(id)
+
+		var err error
+		price, err = patch(current)
+		if err != nil {
+			return err
 		}
+		price.ID = id
 
-		rates = append(rates, price)
+		return next.replacePrice(price)
+	})
+	if err != nil {
+		return Price{}, err
 	}
 
-	if len(rates) == 0 {
-		return FinalPrice{}, errors.New("no matching price found")
+	return price, nil
+}
+
+// DeletePrice removes the price with id.
+func (imr *InMemoryRepository) DeletePrice(ctx context.Context, id int) error {
+	return imr.update(ctx, func(next *snapshot) error {
+		if _, ok := next.priceKeys.get(id); !ok {
+			return errPriceIDNotFound(id)
+		}
+
+		next.deletePrice(id)
+
+		return nil
+	})
+}
+
+// RenameBrand changes the name of the brand with id.
+func (imr *InMemoryRepository) RenameBrand(ctx context.Context, id int, name string) (Brand, error) {
+	err := imr.update(ctx, func(next *snapshot) error {
+		oldName, ok := next.brandNames.get(id)
+		if !ok {
+			return errBrandIDNotFound(id)
+		}
+
+		if existing, ok := next.brands.get(name); ok && existing != id {
+			return errBrandExists(name)
+		}
+
+		next.brands.delete(oldName)
+		next.brands.set(name, id)
+		next.brandNames.set(id, name)
+
+		return nil
+	})
+	if err != nil {
+		return Brand{}, err
 	}
 
-	// O(m) walk applicable prices and find highest priority
-	pvp := rates[0]
-	for _, price := range rates {
-		if price.Priority > pvp.Priority {
-			pvp = price
+	return Brand{ID: id, Name: name}, nil
+}
+
+// DeleteBrand removes the brand with id, refusing if prices or promotions
+// reference it.
+func (imr *InMemoryRepository) DeleteBrand(ctx context.Context, id int) error {
+	return imr.update(ctx, func(next *snapshot) error {
+		name, ok := next.brandNames.get(id)
+		if !ok {
+			return errBrandIDNotFound(id)
+		}
+
+		var err error
+		next.prices.all(func(key productKey, _ *priceIndex) bool {
+			if err = ctx.Err(); err != nil {
+				err = fmt.Errorf("failed to delete brand: %w", err)
+				return false
+			}
+
+			if key.brandID == id {
+				err = errBrandHasPrices(id)
+				return false
+			}
+
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		next.promotions.all(func(_ int, promo Promotion) bool {
+			if promo.BrandID == id {
+				err = errBrandHasPromotions(id)
+				return false
+			}
+
+			return true
+		})
+		if err != nil {
+			return err
 		}
+
+		next.brands.delete(name)
+		next.brandNames.delete(id)
+
+		return nil
+	})
+}
+
+// AddPromotion stores promotion with a generated ID.
+func (imr *InMemoryRepository) AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error) {
+	// the caller keeps its slice, published snapshots must not change
+	promotion.ProductIDs = slices.Clone(promotion.ProductIDs)
+
+	err := imr.update(ctx, func(next *snapshot) error {
+		if _, ok := next.brandNames.get(promotion.BrandID); !ok {
+			return errBrandDoesNotExist()
+		}
+
+		next.lastPromotionID++
+		promotion.ID = next.lastPromotionID
+		next.promotions.set(promotion.ID, promotion)
+
+		for _, productID := range promotion.ProductIDs {
+			key := productKey{brandID: promotion.BrandID, productID: productID}
+			ids, _ := next.productPromotions.get(key)
+			// IDs only increase so appending to a clipped copy keeps them sorted
+			next.productPromotions.set(key, append(slices.Clip(ids), promotion.ID))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Promotion{}, err
 	}
 
-	return FinalPrice{
-		BrandID:   pvp.BrandID,
-		StartDate: pvp.StartDate,
-		EndDate:   pvp.EndDate,
-		ProductID: pvp.ProductID,
-		Price:     pvp.Price,
-		Curr:      pvp.Curr,
-	}, nil
+	return clonePromotion(promotion), nil
+}
+
+// GetPromotionByID returns the promotion with id.
+func (imr *InMemoryRepository) GetPromotionByID(ctx context.Context, id int) (Promotion, error) {
+	promotion, ok := imr.load().promotions.get(id)
+	if !ok {
+		return Promotion{}, errPromotionIDNotFound(id)
+	}
+
+	return clonePromotion(promotion), nil
+}
+
+// GetPromotions returns the promotions including any of productIDs that apply
+// between from and to from a single snapshot.
+func (imr *InMemoryRepository) GetPromotions(ctx context.Context, brandID int, productIDs []int, from, to time.Time) ([]Promotion, error) {
+	snap := imr.load()
+
+	seen := map[int]bool{}
+	promotions := make([]Promotion, 0)
+	for _, productID := range productIDs {
+		ids, _ := snap.productPromotions.get(productKey{brandID: brandID, productID: productID})
+		for _, id := range ids {
+			promotion, _ := snap.promotions.get(id)
+			if seen[id] || promotion.StartDate.After(to) || promotion.EndDate.Before(from) {
+				continue
+			}
+			seen[id] = true
+
+			promotions = append(promotions, clonePromotion(promotion))
+		}
+	}
+
+	sort.Slice(promotions, func(i, j int) bool { return promotions[i].ID < promotions[j].ID })
+
+	return promotions, nil
+}
+
+// DeletePromotion removes the promotion with id.
+func (imr *InMemoryRepository) DeletePromotion(ctx context.Context, id int) error {
+	return imr.update(ctx, func(next *snapshot) error {
+		promotion, ok := next.promotions.get(id)
+		if !ok {
+			return errPromotionIDNotFound(id)
+		}
+
+		for _, productID := range promotion.ProductIDs {
+			key := productKey{brandID: promotion.BrandID, productID: productID}
+			ids, _ := next.productPromotions.get(key)
+			ids = slices.DeleteFunc(slices.Clone(ids), func(other int) bool { return other == id })
+			if len(ids) > 0 {
+				next.productPromotions.set(key, ids)
+			} else {
+				next.productPromotions.delete(key)
+			}
+		}
+		next.promotions.delete(id)
+
+		return nil
+	})
+}
+
+// clonePromotion copies promotion so callers can't modify a published
+// snapshot through its ProductIDs.
+func clonePromotion(promotion Promotion) Promotion {
+	promotion.ProductIDs = slices.Clone(promotion.ProductIDs)
+
+	return promotion
+}
+
+// checkConflicts returns a *PriceConflictError if price overlaps other prices
+// with the same priority.
+func (next *snapshot) checkConflicts(price Price) error {
+	pi, _ := next.prices.get(productKey{brandID: price.BrandID, productID: price.ProductID})
+	if ids := pi.conflicts(price); len(ids) > 0 {
+		return &PriceConflictError{IDs: ids}
+	}
+
+	return nil
+}
+
+// addPrice indexes price, which must have an ID, in the unpublished snapshot.
+func (next *snapshot) addPrice(price Price) {
+	key := productKey{brandID: price.BrandID, productID: price.ProductID}
+	pi, _ := next.prices.get(key)
+	next.prices.set(key, pi.with(price))
+	next.priceKeys.set(price.ID, key)
+}
+
+// replacePrice replaces the stored price with the same ID in the unpublished
+// snapshot, checking its brand exists and it doesn't conflict.
+func (next *snapshot) replacePrice(price Price) error {
+	if _, ok := next.brandNames.get(price.BrandID); !ok {
+		return errBrandDoesNotExist()
+	}
+
+	if err := next.checkConflicts(price); err != nil {
+		return err
+	}
+
+	next.deletePrice(price.ID)
+	next.addPrice(price)
+
+	return nil
+}
+
+// deletePrice removes the price with id from the unpublished snapshot.
+func (next *snapshot) deletePrice(id int) {
+	key, _ := next.priceKeys.get(id)
+	pi, _ := next.prices.get(key)
+	if pi = pi.without(id); pi != nil {
+		next.prices.set(key, pi)
+	} else {
+		next.prices.delete(key)
+	}
+	next.priceKeys.delete(id)
+}
+
+func (imr *InMemoryRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
+	// O(1) find the brand's product then O(log n) find the prices applying
+	pi, _ := imr.load().prices.get(productKey{brandID: brandID, productID: productID})
+	seg, ok := pi.lookup(date)
+	if !ok {
+		return FinalPrice{}, errPriceNotFound(brandID, productID, date)
+	}
+
+	return seg.finalPrice(), nil
+}
+
+// GetPrices looks up every product in the same snapshot so the prices are
+// consistent with each other.
+func (imr *InMemoryRepository) GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) (map[int]FinalPrice, error) {
+	snap := imr.load()
+
+	prices := make(map[int]FinalPrice, len(productIDs))
+	for _, productID := range productIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get prices: %w", err)
+		}
+
+		pi, _ := snap.prices.get(productKey{brandID: brandID, productID: productID})
+		seg, ok := pi.lookup(date)
+		if !ok {
+			continue
+		}
+
+		prices[productID] = seg.finalPrice()
+	}
+
+	return prices, nil
+}
+
+// GetPriceTimeline returns the prices applying between from and to from the
+// same index as GetPrice.
+func (imr *InMemoryRepository) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error) {
+	pi, _ := imr.load().prices.get(productKey{brandID: brandID, productID: productID})
+
+	return pi.timeline(from, to), nil
 }
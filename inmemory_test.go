@@ -2,10 +2,15 @@ package pricing_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/karlskewes/pricing"
 )
 
@@ -22,6 +27,20 @@ func TestNewInMemoryRepository(t *testing.T) {
 	}
 }
 
+func TestInMemoryRepository(t *testing.T) {
+	for name, test := range repositoryTests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			db, err := pricing.NewInMemoryRepository(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			test(t, db)
+		})
+	}
+}
+
 func TestInMemory_AddPrice(t *testing.T) {
 	ctx := context.Background()
 
@@ -30,7 +49,7 @@ func TestInMemory_AddPrice(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -44,15 +63,26 @@ func TestInMemory_AddPrice(t *testing.T) {
 		EndDate:   endDate,
 		ProductID: 3,
 		Priority:  1,
-		Price:     100,
-		Curr:      "EUR",
+		Price:     pricing.Money{Amount: 100, Currency: "EUR"},
 	}
 
-	err = db.AddPrice(ctx, price)
+	got, err := db.AddPrice(ctx, price)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if got.ID != 1 {
+		t.Errorf("want generated id: 1 - got: %d", got.ID)
+	}
+
+	// brand 2 doesn't exist
+	price.BrandID = 2
+	_, err = db.AddPrice(ctx, price)
+	var ve *pricing.ValidationError
+	if !errors.As(err, &ve) || ve.Field != "brand_id" {
+		t.Errorf("want brand_id validation error - got: %v", err)
+	}
+
 	if err := db.Shutdown(ctx); err != nil {
 		t.Fatal(err)
 	}
@@ -66,7 +96,7 @@ func TestInMemory_GetPrice(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -85,22 +115,23 @@ func TestInMemory_GetPrice(t *testing.T) {
 		EndDate:   endDate,
 		ProductID: 3,
 		Priority:  1,
-		Price:     100,
-		Curr:      "EUR",
+		Price:     pricing.Money{Amount: 100, Currency: "EUR"},
 	}
 
-	err = db.AddPrice(ctx, price)
+	_, err = db.AddPrice(ctx, price)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	want := pricing.FinalPrice{
-		BrandID:   price.BrandID,
-		StartDate: price.StartDate,
-		EndDate:   price.EndDate,
-		ProductID: price.ProductID,
-		Price:     price.Price,
-		Curr:      price.Curr,
+		ID:                 1,
+		BrandID:            price.BrandID,
+		StartDate:          price.StartDate,
+		EndDate:            price.EndDate,
+		EffectiveStartDate: price.StartDate,
+		EffectiveEndDate:   price.EndDate,
+		ProductID:          price.ProductID,
+		Price:              price.Price,
 	}
 
 	// test inside of start & end dates, should be matching price
@@ -134,13 +165,13 @@ func TestInMemory_AddBrand(t *testing.T) {
 
 	brandName := "EXAMPLE"
 
-	err = db.AddBrand(ctx, brandName)
+	_, err = db.AddBrand(ctx, brandName)
 	if err != nil {
 		t.Error(err)
 	}
 
 	// Add a second brand with the same name
-	err = db.AddBrand(ctx, brandName)
+	_, err = db.AddBrand(ctx, brandName)
 	if err == nil {
 		t.Error("expected duplicate brand error")
 	}
@@ -157,7 +188,7 @@ func TestInMemory_GetBrand(t *testing.T) {
 	brandName := "EXAMPLE"
 	// TODO, add collision
 
-	err = db.AddBrand(ctx, brandName)
+	_, err = db.AddBrand(ctx, brandName)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -171,6 +202,75 @@ func TestInMemory_GetBrand(t *testing.T) {
 	}
 }
 
+// TestInMemory_Concurrent exercises readers and writers concurrently and is
+// intended to be run with -race.
+func TestInMemory_Concurrent(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+	const writers, writes = 4, 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < writes; i++ {
+				if _, err := db.AddBrand(ctx, fmt.Sprintf("BRAND-%d-%d", w, i)); err != nil {
+					t.Error(err)
+				}
+
+				price := pricing.Price{BrandID: 1, StartDate: date, EndDate: date.Add(time.Hour), ProductID: w + 1, Priority: i, Price: pricing.Money{Amount: int64(i), Currency: "EUR"}}
+				if _, err := db.AddPrice(ctx, price); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < writes; i++ {
+				_, _ = db.GetBrand(ctx, fmt.Sprintf("BRAND-%d-%d", w, i))
+				_, _ = db.GetPrice(ctx, 1, w+1, date)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < writers; w++ {
+		got, err := db.GetPrice(ctx, 1, w+1, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// highest priority is the last price written
+		if got.Price.Amount != writes-1 {
+			t.Errorf("product: %d, want: %d - got: %d", w+1, writes-1, got.Price.Amount)
+		}
+	}
+
+	brand, err := db.AddBrand(ctx, "LAST")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 1 + writers*writes + 1; brand.ID != want {
+		t.Errorf("want brand id: %d - got: %d", want, brand.ID)
+	}
+}
+
 func TestInMemory_Shutdown(t *testing.T) {
 	imr := &pricing.InMemoryRepository{}
 	if err := imr.Shutdown(context.Background()); err != nil {
@@ -178,6 +278,60 @@ func TestInMemory_Shutdown(t *testing.T) {
 	}
 }
 
+func TestInMemory_ZeroValue(t *testing.T) {
+	ctx := context.Background()
+	imr := &pricing.InMemoryRepository{}
+
+	if _, err := imr.GetBrand(ctx, "EXAMPLE"); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("want ErrNotFound - got: %v", err)
+	}
+
+	if _, err := imr.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInMemory_ContextDone(t *testing.T) {
+	db, err := pricing.NewInMemoryRepository(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pricing.SeedExampleData(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := map[string]func() error{
+		"ListPrices": func() error {
+			_, err := db.ListPrices(ctx, pricing.PriceFilter{})
+			return err
+		},
+		"GetPrices": func() error {
+			_, err := db.GetPrices(ctx, 1, []int{35455}, time.Now())
+			return err
+		},
+		"AddBrand": func() error {
+			_, err := db.AddBrand(ctx, "OTHER")
+			return err
+		},
+		"DeleteBrand": func() error {
+			return db.DeleteBrand(ctx, 1)
+		},
+	}
+
+	for name, fn := range testCases {
+		if err := fn(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s want context.Canceled - got: %v", name, err)
+		}
+	}
+
+	if _, err := db.GetBrand(context.Background(), "OTHER"); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("brand added with cancelled context, want ErrNotFound - got: %v", err)
+	}
+}
+
 func TestGetPrices(t *testing.T) {
 	ctx := context.Background()
 
@@ -186,7 +340,7 @@ func TestGetPrices(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -197,7 +351,7 @@ func TestGetPrices(t *testing.T) {
 	}
 
 	for _, price := range prices {
-		err := db.AddPrice(ctx, price)
+		_, err := db.AddPrice(ctx, price)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -220,12 +374,124 @@ func TestGetPrices(t *testing.T) {
 				brandID:   1,
 			},
 			want: pricing.FinalPrice{
-				BrandID:   1,
-				StartDate: prices[0].StartDate,
-				EndDate:   prices[0].EndDate,
-				ProductID: 35455,
-				Price:     3550,
-				Curr:      "EUR",
+				ID:                 1,
+				BrandID:            1,
+				StartDate:          prices[0].StartDate,
+				EndDate:            prices[0].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 14, 59, 59, 999999999, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"Test 2": {
+			test: test{
+				date:      time.Date(2020, 06, 14, 16, 0, 0, 0, time.UTC),
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 2,
+				BrandID:            1,
+				StartDate:          prices[1].StartDate,
+				EndDate:            prices[1].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 15, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 18, 30, 0, 0, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 2545, Currency: "EUR"},
+				CompareAtPriceID:   1,
+				CompareAtPrice:     pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"Test 3": {
+			test: test{
+				date:      time.Date(2020, 06, 14, 21, 0, 0, 0, time.UTC),
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 1,
+				BrandID:            1,
+				StartDate:          prices[0].StartDate,
+				EndDate:            prices[0].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 18, 30, 0, 1, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 23, 59, 59, 999999999, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"Test 4": {
+			test: test{
+				date:      time.Date(2020, 06, 15, 10, 0, 0, 0, time.UTC),
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 3,
+				BrandID:            1,
+				StartDate:          prices[2].StartDate,
+				EndDate:            prices[2].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 15, 0, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 15, 11, 0, 0, 0, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3050, Currency: "EUR"},
+				CompareAtPriceID:   1,
+				CompareAtPrice:     pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"Test 5": {
+			test: test{
+				date:      time.Date(2020, 06, 16, 21, 0, 0, 0, time.UTC),
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 4,
+				BrandID:            1,
+				StartDate:          prices[3].StartDate,
+				EndDate:            prices[3].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 15, 16, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3895, Currency: "EUR"},
+				CompareAtPriceID:   1,
+				CompareAtPrice:     pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"boundary end inclusive": {
+			test: test{
+				date:      prices[1].EndDate,
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 2,
+				BrandID:            1,
+				StartDate:          prices[1].StartDate,
+				EndDate:            prices[1].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 15, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 18, 30, 0, 0, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 2545, Currency: "EUR"},
+				CompareAtPriceID:   1,
+				CompareAtPrice:     pricing.Money{Amount: 3550, Currency: "EUR"},
+			},
+		},
+		"boundary just after end": {
+			test: test{
+				date:      prices[1].EndDate.Add(time.Nanosecond),
+				productID: 35455,
+				brandID:   1,
+			},
+			want: pricing.FinalPrice{
+				ID:                 1,
+				BrandID:            1,
+				StartDate:          prices[0].StartDate,
+				EndDate:            prices[0].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 18, 30, 0, 1, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 23, 59, 59, 999999999, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3550, Currency: "EUR"},
 			},
 		},
 	}
@@ -243,3 +509,317 @@ func TestGetPrices(t *testing.T) {
 		})
 	}
 }
+
+// linearGetPrice is the original InMemoryRepository.GetPrice O(n) scan kept as
+// a reference implementation to compare the indexed lookup against.
+func linearGetPrice(prices []pricing.Price, brandID, productID int, date time.Time) (pricing.FinalPrice, bool) {
+	rates := make([]pricing.Price, 0)
+	for _, price := range prices {
+		if price.BrandID != brandID || price.ProductID != productID {
+			continue
+		}
+		if price.StartDate.After(date) || price.EndDate.Before(date) {
+			continue
+		}
+
+		rates = append(rates, price)
+	}
+
+	if len(rates) == 0 {
+		return pricing.FinalPrice{}, false
+	}
+
+	pvp := rates[0]
+	for _, price := range rates {
+		if price.Priority > pvp.Priority {
+			pvp = price
+		}
+	}
+
+	fp := pricing.FinalPrice{
+		ID:        pvp.ID,
+		BrandID:   pvp.BrandID,
+		StartDate: pvp.StartDate,
+		EndDate:   pvp.EndDate,
+		ProductID: pvp.ProductID,
+		Price:     pvp.Price,
+	}
+
+	// compare at the highest priority of the remaining prices
+	var compareAt *pricing.Price
+	for i, price := range rates {
+		if price.ID != pvp.ID && (compareAt == nil || price.Priority > compareAt.Priority) {
+			compareAt = &rates[i]
+		}
+	}
+	if compareAt != nil {
+		fp.CompareAtPriceID = compareAt.ID
+		fp.CompareAtPrice = compareAt.Price
+	}
+
+	return fp, true
+}
+
+var randomPricesEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// randomPrices returns n overlapping prices for brand 1 spread over products
+// with pricesPerProduct each, all within a year of randomPricesEpoch.
+// Priorities are unique per product so equal priority prices never overlap.
+func randomPrices(rnd *rand.Rand, n, pricesPerProduct int) []pricing.Price {
+	prices := make([]pricing.Price, 0, n)
+	var priorities []int
+	for i := 0; i < n; i++ {
+		if i%pricesPerProduct == 0 {
+			priorities = rnd.Perm(pricesPerProduct)
+		}
+
+		start := randomPricesEpoch.Add(time.Duration(rnd.Intn(365*24)) * time.Hour)
+		prices = append(prices, pricing.Price{
+			ID:        i + 1, // matches the ID the repository generates
+			BrandID:   1,
+			StartDate: start,
+			EndDate:   start.Add(time.Duration(1+rnd.Intn(30*24)) * time.Hour),
+			ProductID: 1 + i/pricesPerProduct,
+			Priority:  priorities[i%pricesPerProduct],
+			Price:     pricing.Money{Amount: int64(rnd.Intn(10000)), Currency: "EUR"},
+		})
+	}
+
+	return prices
+}
+
+func newRandomInMemoryRepository(tb testing.TB, prices []pricing.Price) *pricing.InMemoryRepository {
+	tb.Helper()
+
+	ctx := context.Background()
+
+	db, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	if _, err := db.AddBrand(ctx, "EXAMPLE"); err != nil {
+		tb.Fatal(err)
+	}
+
+	for _, price := range prices {
+		if _, err := db.AddPrice(ctx, price); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// TestInMemory_GetPriceMatchesLinearScan compares the indexed lookup with the
+// original linear scan for random overlapping prices and dates, including
+// boundaries.
+func TestInMemory_GetPriceMatchesLinearScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	prices := randomPrices(rnd, 500, 50)
+	db := newRandomInMemoryRepository(t, prices)
+
+	dates := make([]time.Time, 0)
+	for i := 0; i < 1000; i++ {
+		dates = append(dates, randomPricesEpoch.Add(time.Duration(rnd.Int63n(int64(400*24*time.Hour)))))
+	}
+	for _, price := range prices[:100] {
+		dates = append(dates, price.StartDate, price.StartDate.Add(-time.Nanosecond), price.EndDate, price.EndDate.Add(time.Nanosecond))
+	}
+
+	for _, date := range dates {
+		productID := 1 + rnd.Intn(11) // includes a product without prices
+
+		want, found := linearGetPrice(prices, 1, productID, date)
+
+		got, err := db.GetPrice(context.Background(), 1, productID, date)
+		if found != (err == nil) {
+			t.Fatalf("product: %d, date: %s: want found: %t - got err: %v", productID, date, found, err)
+		}
+
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(pricing.FinalPrice{}, "EffectiveStartDate", "EffectiveEndDate")); diff != "" {
+			t.Fatalf("product: %d, date: %s: db.GetPrice(...) mismatch (-want +got):\n%s", productID, date, diff)
+		}
+
+		if !found {
+			continue
+		}
+
+		// the same price applies at both ends of the effective window and a
+		// different or no price applies just outside it.
+		for _, at := range []time.Time{got.EffectiveStartDate, got.EffectiveEndDate} {
+			if fp, _ := linearGetPrice(prices, 1, productID, at); fp.ID != got.ID {
+				t.Fatalf("product: %d, date: %s: want price: %d at effective bound: %s - got: %d", productID, date, got.ID, at, fp.ID)
+			}
+		}
+		for _, at := range []time.Time{got.EffectiveStartDate.Add(-time.Nanosecond), got.EffectiveEndDate.Add(time.Nanosecond)} {
+			if fp, _ := linearGetPrice(prices, 1, productID, at); fp.ID == got.ID {
+				t.Fatalf("product: %d, date: %s: want different price outside effective bound: %s", productID, date, at)
+			}
+		}
+	}
+}
+
+// TestInMemory_TimelineMatchesLinearScan checks random timelines are
+// contiguous and that the linear scan agrees with every segment's price at its
+// bounds.
+func TestInMemory_TimelineMatchesLinearScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	prices := randomPrices(rnd, 500, 50)
+	db := newRandomInMemoryRepository(t, prices)
+
+	for i := 0; i < 100; i++ {
+		productID := 1 + rnd.Intn(11) // includes a product without prices
+		from := randomPricesEpoch.Add(time.Duration(rnd.Int63n(int64(400 * 24 * time.Hour))))
+		to := from.Add(time.Duration(rnd.Int63n(int64(60 * 24 * time.Hour))))
+
+		got, err := db.GetPriceTimeline(context.Background(), 1, productID, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) == 0 || !got[0].StartDate.Equal(from) || !got[len(got)-1].EndDate.Equal(to) {
+			t.Fatalf("product: %d, from: %s, to: %s: timeline doesn't cover the range: %v", productID, from, to, got)
+		}
+
+		for j, seg := range got {
+			if j > 0 {
+				prev := got[j-1]
+				if !seg.StartDate.Equal(prev.EndDate.Add(time.Nanosecond)) || seg.PriceID == prev.PriceID {
+					t.Fatalf("product: %d: segment %d isn't contiguous with or distinct from the previous: %v, %v", productID, j, prev, seg)
+				}
+			}
+
+			for _, at := range []time.Time{seg.StartDate, seg.EndDate} {
+				if fp, _ := linearGetPrice(prices, 1, productID, at); fp.ID != seg.PriceID || fp.Price != seg.Price {
+					t.Fatalf("product: %d, at: %s: want price: %d - got: %d", productID, at, fp.ID, seg.PriceID)
+				}
+			}
+		}
+	}
+}
+
+// TestInMemory_AddPricesMatchesAddPrice checks a repository built from one
+// batch resolves the same prices as one built a price at a time.
+func TestInMemory_AddPricesMatchesAddPrice(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	prices := randomPrices(rnd, 5000, 50)
+	want := newRandomInMemoryRepository(t, prices)
+
+	ctx := context.Background()
+
+	got, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := got.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := got.AddPrices(ctx, prices); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		productID := 1 + rnd.Intn(101)
+		date := randomPricesEpoch.Add(time.Duration(rnd.Int63n(int64(400 * 24 * time.Hour))))
+
+		wantPrice, wantErr := want.GetPrice(ctx, 1, productID, date)
+		gotPrice, gotErr := got.GetPrice(ctx, 1, productID, date)
+		if (wantErr == nil) != (gotErr == nil) {
+			t.Fatalf("product: %d, date: %s: want err: %v - got: %v", productID, date, wantErr, gotErr)
+		}
+		if diff := cmp.Diff(wantPrice, gotPrice); diff != "" {
+			t.Fatalf("product: %d, date: %s: GetPrice(...) mismatch (-want +got):\n%s", productID, date, diff)
+		}
+	}
+}
+
+// BenchmarkInMemory_GetPrice compares the indexed lookup with the original
+// linear scan as the number of prices grows, both across products and within a
+// single product's prices where the index binary searches. Run with:
+// go test -run=^$ -bench=GetPrice -benchmem
+func BenchmarkInMemory_GetPrice(b *testing.B) {
+	for _, bc := range []struct{ n, pricesPerProduct int }{
+		{1_000, 100},
+		{10_000, 100},
+		{100_000, 100},
+		{10_000, 1_000},
+		{100_000, 10_000},
+		{100_000, 100_000},
+	} {
+		rnd := rand.New(rand.NewSource(1))
+		prices := randomPrices(rnd, bc.n, bc.pricesPerProduct)
+		products := bc.n / bc.pricesPerProduct
+		date := randomPricesEpoch.Add(180 * 24 * time.Hour)
+		// built once, b.Run calls the indexed benchmark for every b.N round
+		db := newBatchInMemoryRepository(b, prices)
+		name := fmt.Sprintf("prices=%d/per_product=%d", bc.n, bc.pricesPerProduct)
+
+		b.Run("linear/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearGetPrice(prices, 1, 1+i%products, date)
+			}
+		})
+
+		b.Run("indexed/"+name, func(b *testing.B) {
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = db.GetPrice(ctx, 1, 1+i%products, date)
+			}
+		})
+	}
+}
+
+// BenchmarkInMemory_AddPrice bounds the cost of adding a price to a product
+// that already has many overlapping prices, which copies the product's index.
+// Run with: go test -run=^$ -bench=AddPrice -benchmem
+func BenchmarkInMemory_AddPrice(b *testing.B) {
+	for _, pricesPerProduct := range []int{100, 1_000, 10_000} {
+		rnd := rand.New(rand.NewSource(1))
+		prices := randomPrices(rnd, pricesPerProduct, pricesPerProduct)
+
+		b.Run(fmt.Sprintf("per_product=%d", pricesPerProduct), func(b *testing.B) {
+			ctx := context.Background()
+
+			var db *pricing.InMemoryRepository
+			for i := 0; i < b.N; i++ {
+				// start over so the product has between 1x and 2x the prices
+				if i%pricesPerProduct == 0 {
+					b.StopTimer()
+					db = newBatchInMemoryRepository(b, prices)
+					b.StartTimer()
+				}
+
+				price := prices[i%pricesPerProduct]
+				price.Priority = pricesPerProduct + i%pricesPerProduct // above and unlike existing prices
+				if _, err := db.AddPrice(ctx, price); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// newBatchInMemoryRepository is newRandomInMemoryRepository adding the prices
+// in a single batch, faster for large benchmarks.
+func newBatchInMemoryRepository(tb testing.TB, prices []pricing.Price) *pricing.InMemoryRepository {
+	tb.Helper()
+
+	ctx := context.Background()
+
+	db, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	if _, err := db.AddBrand(ctx, "EXAMPLE"); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := db.AddPrices(ctx, prices); err != nil {
+		tb.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// loggerKey is the context key of the request scoped logger.
+type loggerKey struct{}
+
+// contextWithLogger returns a copy of ctx carrying logger.
+func contextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFromContext returns the logger carried by ctx, or fallback if none.
+func loggerFromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return fallback
+}
+
+// requestLogger returns the logger of the request set by Handler.AccessLog,
+// or slog.Default if the Handler is used without it.
+func requestLogger(req *http.Request) *slog.Logger {
+	return loggerFromContext(req.Context(), slog.Default())
+}
+
+// AccessLog logs every request with its method, path, status, latency,
+// string_id and any trace ID once handled. Errors while handling are logged
+// with the same attributes. clock times requests, usually time.Now.
+func (h Handler) AccessLog(clock func() time.Time) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			logger := h.logger.With(
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("string_id", req.URL.Query().Get("string_id")),
+			)
+			if sc := trace.SpanContextFromContext(req.Context()); sc.IsValid() {
+				logger = logger.With(slog.String("trace_id", sc.TraceID().String()))
+			}
+
+			sw := &statusWriter{ResponseWriter: w}
+			start := clock()
+			next.ServeHTTP(sw, req.WithContext(contextWithLogger(req.Context(), logger)))
+
+			level := slog.LevelInfo
+			if sw.statusCode() >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(req.Context(), level, "http request",
+				slog.Int("status", sw.statusCode()),
+				slog.Duration("latency", clock().Sub(start)),
+			)
+		})
+	}
+}
+
+// queryLogger logs SQL queries at debug level as a pgx.QueryTracer.
+type queryLogger struct {
+	logger *slog.Logger
+}
+
+// queryStartKey is the context key of the query start time and SQL.
+type queryStartKey struct{}
+
+type queryStart struct {
+	time time.Time
+	data pgx.TraceQueryStartData
+}
+
+func (ql queryLogger) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !ql.logger.Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, queryStartKey{}, queryStart{time: time.Now(), data: data})
+}
+
+func (ql queryLogger) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", start.data.SQL),
+		slog.Any("args", start.data.Args),
+		slog.Duration("duration", time.Since(start.time)),
+		slog.String("command_tag", data.CommandTag.String()),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	}
+
+	ql.logger.LogAttrs(ctx, slog.LevelDebug, "sql query", attrs...)
+}
@@ -0,0 +1,340 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsNamespace prefixes the name of every metric, e.g:
+// pricing_http_requests_total.
+const metricsNamespace = "pricing"
+
+// Metrics are the Prometheus collectors of HTTP requests, price lookups and
+// Repository operations. A nil *Metrics records nothing.
+type Metrics struct {
+	clock        func() time.Time
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	priceLookups *prometheus.CounterVec
+	repoDuration *prometheus.HistogramVec
+}
+
+// NewMetrics creates and registers the collectors with reg. clock times
+// requests and operations, usually time.Now.
+func NewMetrics(reg prometheus.Registerer, clock func() time.Time) (*Metrics, error) {
+	m := &Metrics{
+		clock: clock,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		priceLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "price_lookups_total",
+			Help:      "Final price lookups by brand and result, hit if a price applies otherwise miss. Brands that don't exist are unknown.",
+		}, []string{"brand_id", "result"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Repository operation latency by backend and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.httpRequests, m.httpDuration, m.priceLookups, m.repoDuration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Middleware records the count and latency of requests labelled by the route
+// pattern of mux they match, rather than the path, to bound the number of
+// series. Requests matching no route are labelled "unmatched".
+func (m *Metrics) Middleware(mux *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, pattern := mux.Handler(req)
+			route := "unmatched"
+			if pattern != "" {
+				route = pattern
+				if _, path, ok := strings.Cut(pattern, " "); ok {
+					route = path // the method is a label of its own
+				}
+			}
+
+			sw := &statusWriter{ResponseWriter: w}
+			start := m.clock()
+			next.ServeHTTP(sw, req)
+
+			status := strconv.Itoa(sw.statusCode())
+			m.httpRequests.WithLabelValues(req.Method, route, status).Inc()
+			m.httpDuration.WithLabelValues(req.Method, route, status).Observe(m.clock().Sub(start).Seconds())
+		})
+	}
+}
+
+// unknownBrand labels price lookup misses of brands that don't exist. Clients
+// choose the brand_id of a lookup so only stored brands are labelled with it.
+const unknownBrand = "unknown"
+
+// observePriceLookup counts a price lookup result, errors other than
+// ErrNotFound aren't lookups so aren't counted. brandExists is only called
+// for misses, hits imply the brand exists.
+func (m *Metrics) observePriceLookup(brandID int, err error, brandExists func() bool) {
+	if m == nil {
+		return
+	}
+
+	brand, result := strconv.Itoa(brandID), "hit"
+	switch {
+	case errors.Is(err, ErrNotFound):
+		result = "miss"
+		if !brandExists() {
+			brand = unknownBrand
+		}
+	case err != nil:
+		return
+	}
+
+	m.priceLookups.WithLabelValues(brand, result).Inc()
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// statusCode returns the written status, http.StatusOK if the handler wrote
+// nothing.
+func (sw *statusWriter) statusCode() int {
+	if sw.status == 0 {
+		return http.StatusOK
+	}
+
+	return sw.status
+}
+
+// InstrumentRepository returns repo recording the latency of each operation
+// labelled by backend, e.g: postgres. Ping and Shutdown aren't recorded.
+func (m *Metrics) InstrumentRepository(repo Repository, backend string) Repository {
+	return &instrumentedRepository{Repository: repo, metrics: m, backend: backend}
+}
+
+type instrumentedRepository struct {
+	Repository
+	metrics *Metrics
+	backend string
+}
+
+// observe starts timing operation, call the returned func when it completes.
+func (ir *instrumentedRepository) observe(operation string) func() {
+	start := ir.metrics.clock()
+
+	return func() {
+		ir.metrics.repoDuration.WithLabelValues(ir.backend, operation).Observe(ir.metrics.clock().Sub(start).Seconds())
+	}
+}
+
+func (ir *instrumentedRepository) AddPrice(ctx context.Context, price Price) (Price, error) {
+	defer ir.observe("add_price")()
+	return ir.Repository.AddPrice(ctx, price)
+}
+
+func (ir *instrumentedRepository) AddPrices(ctx context.Context, prices []Price) ([]Price, error) {
+	defer ir.observe("add_prices")()
+	return ir.Repository.AddPrices(ctx, prices)
+}
+
+func (ir *instrumentedRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
+	defer ir.observe("get_price")()
+	return ir.Repository.GetPrice(ctx, brandID, productID, date)
+}
+
+func (ir *instrumentedRepository) GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) (map[int]FinalPrice, error) {
+	defer ir.observe("get_prices")()
+	return ir.Repository.GetPrices(ctx, brandID, productIDs, date)
+}
+
+func (ir *instrumentedRepository) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error) {
+	defer ir.observe("get_price_timeline")()
+	return ir.Repository.GetPriceTimeline(ctx, brandID, productID, from, to)
+}
+
+func (ir *instrumentedRepository) ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error) {
+	defer ir.observe("list_prices")()
+	return ir.Repository.ListPrices(ctx, filter)
+}
+
+func (ir *instrumentedRepository) GetPriceByID(ctx context.Context, id int) (Price, error) {
+	defer ir.observe("get_price_by_id")()
+	return ir.Repository.GetPriceByID(ctx, id)
+}
+
+func (ir *instrumentedRepository) UpdatePrice(ctx context.Context, price Price) (Price, error) {
+	defer ir.observe("update_price")()
+	return ir.Repository.UpdatePrice(ctx, price)
+}
+
+func (ir *instrumentedRepository) PatchPrice(ctx context.Context, id int, patch func(Price) (Price, error)) (Price, error) {
+	defer ir.observe("patch_price")()
+	return ir.Repository.PatchPrice(ctx, id, patch)
+}
+
+func (ir *instrumentedRepository) DeletePrice(ctx context.Context, id int) error {
+	defer ir.observe("delete_price")()
+	return ir.Repository.DeletePrice(ctx, id)
+}
+
+func (ir *instrumentedRepository) AddBrand(ctx context.Context, name string) (Brand, error) {
+	defer ir.observe("add_brand")()
+	return ir.Repository.AddBrand(ctx, name)
+}
+
+func (ir *instrumentedRepository) GetBrand(ctx context.Context, name string) (Brand, error) {
+	defer ir.observe("get_brand")()
+	return ir.Repository.GetBrand(ctx, name)
+}
+
+func (ir *instrumentedRepository) GetBrandByID(ctx context.Context, id int) (Brand, error) {
+	defer ir.observe("get_brand_by_id")()
+	return ir.Repository.GetBrandByID(ctx, id)
+}
+
+func (ir *instrumentedRepository) RenameBrand(ctx context.Context, id int, name string) (Brand, error) {
+	defer ir.observe("rename_brand")()
+	return ir.Repository.RenameBrand(ctx, id, name)
+}
+
+func (ir *instrumentedRepository) DeleteBrand(ctx context.Context, id int) error {
+	defer ir.observe("delete_brand")()
+	return ir.Repository.DeleteBrand(ctx, id)
+}
+
+func (ir *instrumentedRepository) AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error) {
+	defer ir.observe("add_promotion")()
+	return ir.Repository.AddPromotion(ctx, promotion)
+}
+
+func (ir *instrumentedRepository) GetPromotionByID(ctx context.Context, id int) (Promotion, error) {
+	defer ir.observe("get_promotion_by_id")()
+	return ir.Repository.GetPromotionByID(ctx, id)
+}
+
+func (ir *instrumentedRepository) GetPromotions(ctx context.Context, brandID int, productIDs []int, from, to time.Time) ([]Promotion, error) {
+	defer ir.observe("get_promotions")()
+	return ir.Repository.GetPromotions(ctx, brandID, productIDs, from, to)
+}
+
+func (ir *instrumentedRepository) DeletePromotion(ctx context.Context, id int) error {
+	defer ir.observe("delete_promotion")()
+	return ir.Repository.DeletePromotion(ctx, id)
+}
+
+// poolCollector exports the connection statistics of a pgx pool.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	idleConns            *prometheus.Desc
+	maxConns             *prometheus.Desc
+	totalConns           *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroy   *prometheus.Desc
+	maxIdleDestroy       *prometheus.Desc
+}
+
+// NewPoolCollector returns a collector of the Postgres connection pool
+// statistics.
+func (pg *Postgres) NewPoolCollector() prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:                 pg.pool,
+		acquireCount:         desc("acquire_count_total", "Successful connection acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections from the pool."),
+		acquiredConns:        desc("acquired_conns", "Connections currently acquired from the pool."),
+		canceledAcquireCount: desc("canceled_acquire_count_total", "Acquires from the pool canceled by a context."),
+		constructingConns:    desc("constructing_conns", "Connections currently being constructed."),
+		emptyAcquireCount:    desc("empty_acquire_count_total", "Acquires that waited for a connection because the pool was empty."),
+		idleConns:            desc("idle_conns", "Connections currently idle in the pool."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		totalConns:           desc("total_conns", "Connections currently in the pool."),
+		newConnsCount:        desc("new_conns_count_total", "Connections opened by the pool."),
+		maxLifetimeDestroy:   desc("max_lifetime_destroy_count_total", "Connections closed for exceeding the maximum lifetime."),
+		maxIdleDestroy:       desc("max_idle_destroy_count_total", "Connections closed for exceeding the maximum idle time."),
+	}
+}
+
+func (pc *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(pc, ch)
+}
+
+func (pc *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := pc.pool.Stat()
+
+	for _, m := range []struct {
+		desc  *prometheus.Desc
+		kind  prometheus.ValueType
+		value float64
+	}{
+		{pc.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount())},
+		{pc.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds()},
+		{pc.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns())},
+		{pc.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount())},
+		{pc.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns())},
+		{pc.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount())},
+		{pc.idleConns, prometheus.GaugeValue, float64(stat.IdleConns())},
+		{pc.maxConns, prometheus.GaugeValue, float64(stat.MaxConns())},
+		{pc.totalConns, prometheus.GaugeValue, float64(stat.TotalConns())},
+		{pc.newConnsCount, prometheus.CounterValue, float64(stat.NewConnsCount())},
+		{pc.maxLifetimeDestroy, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount())},
+		{pc.maxIdleDestroy, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount())},
+	} {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.kind, m.value)
+	}
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Money amounts are int64 in the minor unit, eg: cents, which can exceed INTEGER
+-- for currencies with a small minor unit like IDR or VND.
+ALTER TABLE price ALTER COLUMN price TYPE BIGINT;
+
+-- +goose Down
+ALTER TABLE price ALTER COLUMN price TYPE INTEGER;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Brand names are looked up by name so must be unique, matching the in-memory
+-- repository. The original UNIQUE(id, name) is implied by the primary key.
+-- Existing duplicates are reported, rather than failing on the constraint, so
+-- they can be renamed or merged before starting again.
+-- +goose StatementBegin
+DO $$
+DECLARE
+  duplicates text;
+BEGIN
+  SELECT string_agg(format('%s (ids: %s)', name, ids), ', ' ORDER BY name) INTO duplicates
+  FROM (
+    SELECT name, string_agg(id::text, ', ' ORDER BY id) AS ids
+    FROM brand GROUP BY name HAVING count(*) > 1
+  ) AS d;
+
+  IF duplicates IS NOT NULL THEN
+    RAISE EXCEPTION 'brand names must be unique, duplicates: %', duplicates
+      USING HINT = 'Rename all but one brand of each name, or move their prices to it and delete them, then restart.';
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+ALTER TABLE brand ADD CONSTRAINT brand_name_key UNIQUE (name);
+
+-- +goose Down
+ALTER TABLE brand DROP CONSTRAINT IF EXISTS brand_name_key;
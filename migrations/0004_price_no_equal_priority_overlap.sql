@@ -0,0 +1,35 @@
+-- +goose Up
+-- Prices of the same brand and product with the same priority can't overlap,
+-- otherwise which applies is ambiguous. The exclusion constraint is checked
+-- atomically so concurrent inserts can't both succeed.
+-- btree_gist is a trusted extension so database owners can create it.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Existing overlaps are reported, rather than failing on the constraint, so
+-- they can be resolved before starting again.
+-- +goose StatementBegin
+DO $$
+DECLARE
+  overlaps text;
+BEGIN
+  SELECT string_agg(format('%s and %s', a.id, b.id), ', ' ORDER BY a.id, b.id) INTO overlaps
+  FROM price a
+  JOIN price b ON b.brand_id = a.brand_id AND b.product_id = a.product_id AND b.priority = a.priority
+    AND b.id > a.id AND b.start_date <= a.end_date AND a.start_date <= b.end_date;
+
+  IF overlaps IS NOT NULL THEN
+    RAISE EXCEPTION 'prices with the same brand, product and priority overlap, ids: %', overlaps
+      USING HINT = 'Change the priority or dates of, or delete, one price of each pair, then restart.';
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+ALTER TABLE price ADD CONSTRAINT price_no_equal_priority_overlap EXCLUDE USING gist (
+  brand_id WITH =,
+  product_id WITH =,
+  priority WITH =,
+  tsrange(start_date, end_date, '[]') WITH && -- inclusive of start & end dates
+);
+
+-- +goose Down
+ALTER TABLE price DROP CONSTRAINT IF EXISTS price_no_equal_priority_overlap;
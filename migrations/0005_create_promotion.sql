@@ -0,0 +1,26 @@
+-- +goose Up
+-- Promotions discount the base price of a brand's products, either by a
+-- percentage or a fixed amount in the currency curr.
+CREATE TABLE promotion (
+  id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  brand_id INTEGER NOT NULL,
+  product_ids INTEGER[] NOT NULL,
+  start_date TIMESTAMP WITHOUT TIME ZONE NOT NULL, -- use UTC for times
+  end_date TIMESTAMP WITHOUT TIME ZONE NOT NULL, -- use UTC for times
+  percent_off INTEGER NOT NULL DEFAULT 0,
+  amount_off BIGINT NOT NULL DEFAULT 0, -- lowest unit, eg: cents in USD, yen in JPY
+  curr TEXT NOT NULL DEFAULT '',
+  CHECK (start_date <= end_date),
+  CHECK (cardinality(product_ids) > 0),
+  CHECK (percent_off BETWEEN 0 AND 100 AND amount_off >= 0),
+  CHECK ((percent_off > 0) <> (amount_off > 0)), -- exactly one discount
+  CONSTRAINT fk_promotion_brand_id
+    FOREIGN KEY(brand_id)
+      REFERENCES brand(id)
+);
+
+-- GetPromotions looks up promotions containing any of a brand's products.
+CREATE INDEX promotion_ix_product_ids ON promotion USING gin (product_ids);
+
+-- +goose Down
+DROP TABLE IF EXISTS promotion;
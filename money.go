@@ -0,0 +1,211 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency and the number of digits its minor unit
+// uses after the decimal separator.
+type Currency struct {
+	Code       string // Alphabetic code, e.g: EUR.
+	MinorUnits int    // Digits after the decimal separator, e.g: 2 for EUR, 0 for JPY, 3 for KWD.
+}
+
+// currencies is the ISO 4217 list of active currencies keyed by code.
+// Precious metals, testing and other codes without a minor unit are excluded.
+var currencies = map[string]Currency{
+	"AED": {"AED", 2}, "AFN": {"AFN", 2}, "ALL": {"ALL", 2}, "AMD": {"AMD", 2},
+	"ANG": {"ANG", 2}, "AOA": {"AOA", 2}, "ARS": {"ARS", 2}, "AUD": {"AUD", 2},
+	"AWG": {"AWG", 2}, "AZN": {"AZN", 2}, "BAM": {"BAM", 2}, "BBD": {"BBD", 2},
+	"BDT": {"BDT", 2}, "BGN": {"BGN", 2}, "BHD": {"BHD", 3}, "BIF": {"BIF", 0},
+	"BMD": {"BMD", 2}, "BND": {"BND", 2}, "BOB": {"BOB", 2}, "BOV": {"BOV", 2},
+	"BRL": {"BRL", 2}, "BSD": {"BSD", 2}, "BTN": {"BTN", 2}, "BWP": {"BWP", 2},
+	"BYN": {"BYN", 2}, "BZD": {"BZD", 2}, "CAD": {"CAD", 2}, "CDF": {"CDF", 2},
+	"CHE": {"CHE", 2}, "CHF": {"CHF", 2}, "CHW": {"CHW", 2}, "CLF": {"CLF", 4},
+	"CLP": {"CLP", 0}, "CNY": {"CNY", 2}, "COP": {"COP", 2}, "COU": {"COU", 2},
+	"CRC": {"CRC", 2}, "CUP": {"CUP", 2}, "CVE": {"CVE", 2}, "CZK": {"CZK", 2},
+	"DJF": {"DJF", 0}, "DKK": {"DKK", 2}, "DOP": {"DOP", 2}, "DZD": {"DZD", 2},
+	"EGP": {"EGP", 2}, "ERN": {"ERN", 2}, "ETB": {"ETB", 2}, "EUR": {"EUR", 2},
+	"FJD": {"FJD", 2}, "FKP": {"FKP", 2}, "GBP": {"GBP", 2}, "GEL": {"GEL", 2},
+	"GHS": {"GHS", 2}, "GIP": {"GIP", 2}, "GMD": {"GMD", 2}, "GNF": {"GNF", 0},
+	"GTQ": {"GTQ", 2}, "GYD": {"GYD", 2}, "HKD": {"HKD", 2}, "HNL": {"HNL", 2},
+	"HTG": {"HTG", 2}, "HUF": {"HUF", 2}, "IDR": {"IDR", 2}, "ILS": {"ILS", 2},
+	"INR": {"INR", 2}, "IQD": {"IQD", 3}, "IRR": {"IRR", 2}, "ISK": {"ISK", 0},
+	"JMD": {"JMD", 2}, "JOD": {"JOD", 3}, "JPY": {"JPY", 0}, "KES": {"KES", 2},
+	"KGS": {"KGS", 2}, "KHR": {"KHR", 2}, "KMF": {"KMF", 0}, "KPW": {"KPW", 2},
+	"KRW": {"KRW", 0}, "KWD": {"KWD", 3}, "KYD": {"KYD", 2}, "KZT": {"KZT", 2},
+	"LAK": {"LAK", 2}, "LBP": {"LBP", 2}, "LKR": {"LKR", 2}, "LRD": {"LRD", 2},
+	"LSL": {"LSL", 2}, "LYD": {"LYD", 3}, "MAD": {"MAD", 2}, "MDL": {"MDL", 2},
+	"MGA": {"MGA", 2}, "MKD": {"MKD", 2}, "MMK": {"MMK", 2}, "MNT": {"MNT", 2},
+	"MOP": {"MOP", 2}, "MRU": {"MRU", 2}, "MUR": {"MUR", 2}, "MVR": {"MVR", 2},
+	"MWK": {"MWK", 2}, "MXN": {"MXN", 2}, "MXV": {"MXV", 2}, "MYR": {"MYR", 2},
+	"MZN": {"MZN", 2}, "NAD": {"NAD", 2}, "NGN": {"NGN", 2}, "NIO": {"NIO", 2},
+	"NOK": {"NOK", 2}, "NPR": {"NPR", 2}, "NZD": {"NZD", 2}, "OMR": {"OMR", 3},
+	"PAB": {"PAB", 2}, "PEN": {"PEN", 2}, "PGK": {"PGK", 2}, "PHP": {"PHP", 2},
+	"PKR": {"PKR", 2}, "PLN": {"PLN", 2}, "PYG": {"PYG", 0}, "QAR": {"QAR", 2},
+	"RON": {"RON", 2}, "RSD": {"RSD", 2}, "RUB": {"RUB", 2}, "RWF": {"RWF", 0},
+	"SAR": {"SAR", 2}, "SBD": {"SBD", 2}, "SCR": {"SCR", 2}, "SDG": {"SDG", 2},
+	"SEK": {"SEK", 2}, "SGD": {"SGD", 2}, "SHP": {"SHP", 2}, "SLE": {"SLE", 2},
+	"SOS": {"SOS", 2}, "SRD": {"SRD", 2}, "SSP": {"SSP", 2}, "STN": {"STN", 2},
+	"SVC": {"SVC", 2}, "SYP": {"SYP", 2}, "SZL": {"SZL", 2}, "THB": {"THB", 2},
+	"TJS": {"TJS", 2}, "TMT": {"TMT", 2}, "TND": {"TND", 3}, "TOP": {"TOP", 2},
+	"TRY": {"TRY", 2}, "TTD": {"TTD", 2}, "TWD": {"TWD", 2}, "TZS": {"TZS", 2},
+	"UAH": {"UAH", 2}, "UGX": {"UGX", 0}, "USD": {"USD", 2}, "USN": {"USN", 2},
+	"UYI": {"UYI", 0}, "UYU": {"UYU", 2}, "UYW": {"UYW", 4}, "UZS": {"UZS", 2},
+	"VED": {"VED", 2}, "VES": {"VES", 2}, "VND": {"VND", 0}, "VUV": {"VUV", 0},
+	"WST": {"WST", 2}, "XAF": {"XAF", 0}, "XCD": {"XCD", 2}, "XOF": {"XOF", 0},
+	"XPF": {"XPF", 0}, "YER": {"YER", 2}, "ZAR": {"ZAR", 2}, "ZMW": {"ZMW", 2},
+	"ZWL": {"ZWL", 2},
+}
+
+// LookupCurrency returns the ISO 4217 currency for the provided alphabetic
+// code, e.g: EUR.
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("unknown ISO 4217 currency: %q", code)
+	}
+
+	return c, nil
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, for example
+// 3550 EUR is €35.50 and 3550 JPY is ¥3550.
+type Money struct {
+	Amount   int64  // Amount in the currency's minor unit, e.g: cents in EUR, yen in JPY.
+	Currency string // ISO 4217 alphabetic code.
+}
+
+// NewMoney returns Money for an amount in the minor unit of the provided
+// currency code.
+func NewMoney(amount int64, currency string) (Money, error) {
+	m := Money{Amount: amount, Currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+
+	return m, nil
+}
+
+// ParseMoney parses a decimal amount, e.g: "35.50" or "-0.5", in the major
+// unit of the provided currency code. Amounts with more decimal places than the
+// currency's minor unit supports are rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := amount
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || (hasFrac && frac == "") {
+		return Money{}, fmt.Errorf("invalid amount: %q", amount)
+	}
+	if len(frac) > c.MinorUnits {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", amount, c.MinorUnits, c.Code)
+	}
+
+	digits := whole + frac + strings.Repeat("0", c.MinorUnits-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("invalid amount: %q", amount)
+		}
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount: %q: %w", amount, err)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: c.Code}, nil
+}
+
+// Validate returns an error if the Money currency isn't a known ISO 4217 code.
+func (m Money) Validate() error {
+	_, err := LookupCurrency(m.Currency)
+
+	return err
+}
+
+// Decimal formats the amount in the currency's major unit with the number of
+// decimal places ISO 4217 defines for it, e.g: "35.50" EUR, "3550" JPY,
+// "-1.250" KWD. Amounts in unknown currencies are formatted in the minor unit.
+func (m Money) Decimal() string {
+	c, ok := currencies[m.Currency]
+	if !ok || c.MinorUnits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	// format the absolute value via uint64 so math.MinInt64 doesn't overflow.
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = -abs
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if len(digits) <= c.MinorUnits {
+		digits = strings.Repeat("0", c.MinorUnits-len(digits)+1) + digits
+	}
+	split := len(digits) - c.MinorUnits
+
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// String formats Money as the decimal amount followed by the currency code,
+// e.g: "35.50 EUR".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// moneyJSON is the wire format of Money. The amount is a decimal string in the
+// currency's major unit so clients don't need to know ISO 4217 minor units and
+// large amounts don't lose precision as JSON numbers.
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler, e.g: {"amount":"35.50","currency":"EUR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler and validates the currency and
+// number of decimal places.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var mj moneyJSON
+	if err := json.Unmarshal(data, &mj); err != nil {
+		return err
+	}
+
+	if mj.Amount == "" {
+		return errors.New("money amount cannot be empty")
+	}
+
+	parsed, err := ParseMoney(mj.Amount, mj.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
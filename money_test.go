@@ -0,0 +1,120 @@
+package pricing_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karlskewes/pricing"
+)
+
+func TestMoney_Decimal(t *testing.T) {
+	testCases := map[string]struct {
+		input pricing.Money
+		want  string
+	}{
+		"EUR":              {pricing.Money{Amount: 3550, Currency: "EUR"}, "35.50"},
+		"EUR cents only":   {pricing.Money{Amount: 5, Currency: "EUR"}, "0.05"},
+		"EUR negative":     {pricing.Money{Amount: -5, Currency: "EUR"}, "-0.05"},
+		"EUR zero":         {pricing.Money{Amount: 0, Currency: "EUR"}, "0.00"},
+		"JPY":              {pricing.Money{Amount: 3550, Currency: "JPY"}, "3550"},
+		"JPY negative":     {pricing.Money{Amount: -3550, Currency: "JPY"}, "-3550"},
+		"KWD":              {pricing.Money{Amount: 1250, Currency: "KWD"}, "1.250"},
+		"BHD negative":     {pricing.Money{Amount: -1, Currency: "BHD"}, "-0.001"},
+		"CLF":              {pricing.Money{Amount: 12345, Currency: "CLF"}, "1.2345"},
+		"min int64":        {pricing.Money{Amount: math.MinInt64, Currency: "EUR"}, "-92233720368547758.08"},
+		"unknown currency": {pricing.Money{Amount: 3550, Currency: "ZZZ"}, "3550"},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			if got := tt.input.Decimal(); got != tt.want {
+				t.Errorf("want: %s - got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	testCases := map[string]struct {
+		amount   string
+		currency string
+		want     pricing.Money
+		wantErr  bool
+	}{
+		"EUR":                   {"35.50", "EUR", pricing.Money{Amount: 3550, Currency: "EUR"}, false},
+		"EUR single decimal":    {"35.5", "EUR", pricing.Money{Amount: 3550, Currency: "EUR"}, false},
+		"EUR whole":             {"35", "EUR", pricing.Money{Amount: 3500, Currency: "EUR"}, false},
+		"EUR negative":          {"-0.05", "EUR", pricing.Money{Amount: -5, Currency: "EUR"}, false},
+		"EUR explicit positive": {"+1.00", "EUR", pricing.Money{Amount: 100, Currency: "EUR"}, false},
+		"JPY":                   {"3550", "JPY", pricing.Money{Amount: 3550, Currency: "JPY"}, false},
+		"KWD":                   {"1.25", "KWD", pricing.Money{Amount: 1250, Currency: "KWD"}, false},
+		"EUR too many decimals": {"35.505", "EUR", pricing.Money{}, true},
+		"JPY fractional":        {"35.5", "JPY", pricing.Money{}, true},
+		"unknown currency":      {"35.50", "ZZZ", pricing.Money{}, true},
+		"lowercase currency":    {"35.50", "eur", pricing.Money{}, true},
+		"empty":                 {"", "EUR", pricing.Money{}, true},
+		"trailing separator":    {"35.", "EUR", pricing.Money{}, true},
+		"leading separator":     {".50", "EUR", pricing.Money{}, true},
+		"not a number":          {"35,50", "EUR", pricing.Money{}, true},
+		"overflow":              {"92233720368547758.08", "EUR", pricing.Money{}, true},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := pricing.ParseMoney(tt.amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney(%q, %q) error = %v, wantErr %v", tt.amount, tt.currency, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseMoney(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	if _, err := pricing.NewMoney(100, "EUR"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := pricing.NewMoney(100, "EURO"); err == nil {
+		t.Error("expected unknown currency error")
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	want := pricing.Money{Amount: -1250, Currency: "KWD"}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"amount":"-1.250","currency":"KWD"}` {
+		t.Errorf("unexpected json: %s", data)
+	}
+
+	var got pricing.Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("json round trip mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, input := range []string{
+		`{"amount":"1.00","currency":"JPY"}`,
+		`{"amount":"1.00","currency":"XXX"}`,
+		`{"amount":"","currency":"EUR"}`,
+		`{"amount":1.00,"currency":"EUR"}`,
+	} {
+		if err := json.Unmarshal([]byte(input), &got); err == nil {
+			t.Errorf("expected error unmarshalling: %s", input)
+		}
+	}
+}
@@ -2,14 +2,21 @@ package pricing_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jackc/pgx/v5"
 	"github.com/karlskewes/pricing"
 	"github.com/testcontainers/testcontainers-go"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 const (
@@ -21,7 +28,8 @@ const (
 
 type dbContainer struct {
 	testcontainers.Container
-	connStr string
+	connStr   string
+	databases int // created by newDatabase
 }
 
 func setupDB(ctx context.Context) (*dbContainer, error) {
@@ -79,7 +87,7 @@ func TestAddPrice(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -93,15 +101,26 @@ func TestAddPrice(t *testing.T) {
 		EndDate:   endDate,
 		ProductID: 3,
 		Priority:  1,
-		Price:     100,
-		Curr:      "EUR",
+		Price:     pricing.Money{Amount: 100, Currency: "EUR"},
 	}
 
-	err = db.AddPrice(ctx, price)
+	got, err := db.AddPrice(ctx, price)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if got.ID != 1 {
+		t.Errorf("want generated id: 1 - got: %d", got.ID)
+	}
+
+	// brand 2 doesn't exist
+	price.BrandID = 2
+	_, err = db.AddPrice(ctx, price)
+	var ve *pricing.ValidationError
+	if !errors.As(err, &ve) || ve.Field != "brand_id" {
+		t.Errorf("want brand_id validation error - got: %v", err)
+	}
+
 	if err := db.Shutdown(ctx); err != nil {
 		t.Fatal(err)
 	}
@@ -123,7 +142,7 @@ func TestGetPrice(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -142,22 +161,23 @@ func TestGetPrice(t *testing.T) {
 		EndDate:   endDate,
 		ProductID: 3,
 		Priority:  1,
-		Price:     100,
-		Curr:      "EUR",
+		Price:     pricing.Money{Amount: 100, Currency: "EUR"},
 	}
 
-	err = db.AddPrice(ctx, price)
+	_, err = db.AddPrice(ctx, price)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	want := pricing.FinalPrice{
-		BrandID:   price.BrandID,
-		StartDate: price.StartDate,
-		EndDate:   price.EndDate,
-		ProductID: price.ProductID,
-		Price:     price.Price,
-		Curr:      price.Curr,
+		ID:                 1,
+		BrandID:            price.BrandID,
+		StartDate:          price.StartDate,
+		EndDate:            price.EndDate,
+		EffectiveStartDate: price.StartDate,
+		EffectiveEndDate:   price.EndDate,
+		ProductID:          price.ProductID,
+		Price:              price.Price,
 	}
 
 	// test inside of start & end dates, should be matching price
@@ -197,7 +217,7 @@ func TestPrices(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = db.AddBrand(ctx, "EXAMPLE")
+	_, err = db.AddBrand(ctx, "EXAMPLE")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -208,7 +228,7 @@ func TestPrices(t *testing.T) {
 	}
 
 	for _, price := range prices {
-		err := db.AddPrice(ctx, price)
+		_, err := db.AddPrice(ctx, price)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -231,12 +251,14 @@ func TestPrices(t *testing.T) {
 				brandID:   1,
 			},
 			want: pricing.FinalPrice{
-				BrandID:   1,
-				StartDate: prices[0].StartDate,
-				EndDate:   prices[0].EndDate,
-				ProductID: 35455,
-				Price:     3550,
-				Curr:      "EUR",
+				ID:                 1,
+				BrandID:            1,
+				StartDate:          prices[0].StartDate,
+				EndDate:            prices[0].EndDate,
+				EffectiveStartDate: time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC),
+				EffectiveEndDate:   time.Date(2020, 06, 14, 14, 59, 59, 999999999, time.UTC),
+				ProductID:          35455,
+				Price:              pricing.Money{Amount: 3550, Currency: "EUR"},
 			},
 		},
 	}
@@ -255,4 +277,121 @@ func TestPrices(t *testing.T) {
 	}
 }
 
-// TODO, AddBrand, GetBrand
+// newDatabase creates an empty database in the container and returns a
+// repository using it, shut down when t ends. Tests share the container but
+// not their data.
+func (c *dbContainer) newDatabase(t *testing.T, opts ...pricing.RepositoryOption) *pricing.Postgres {
+	t.Helper()
+
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, c.connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	c.databases++
+	name := fmt.Sprintf("test_%d", c.databases)
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(c.connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = "/" + name
+
+	db, err := pricing.NewPostgresRepository(ctx, u.String(), "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Shutdown(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return db
+}
+
+func TestPostgresRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	dbContainer, err := setupDB(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range repositoryTests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, dbContainer.newDatabase(t))
+		})
+	}
+
+	t.Run("add brand", func(t *testing.T) {
+		ctx := context.Background()
+		db := dbContainer.newDatabase(t)
+
+		brand, err := db.AddBrand(ctx, "EXAMPLE")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if brand.ID != 1 {
+			t.Errorf("want generated id: 1 - got: %d", brand.ID)
+		}
+
+		// Add a second brand with the same name
+		_, err = db.AddBrand(ctx, "EXAMPLE")
+		if !errors.Is(err, pricing.ErrConflict) {
+			t.Errorf("want duplicate brand conflict error - got: %v", err)
+		}
+	})
+
+	t.Run("query spans", func(t *testing.T) {
+		ctx := context.Background()
+		recorder := tracetest.NewSpanRecorder()
+		db := dbContainer.newDatabase(t, pricing.WithRepositoryTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+		if _, err := db.AddBrand(ctx, "EXAMPLE"); err != nil {
+			t.Fatal(err)
+		}
+		prices, err := initialPrices()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddPrices(ctx, prices); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetPrice(ctx, 1, 35455, prices[0].StartDate); err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[string]string{}
+		for _, span := range recorder.Ended() {
+			for _, attr := range span.Attributes() {
+				if attr.Key == semconv.DBOperationNameKey {
+					got[span.Name()] = attr.Value.AsString()
+				}
+			}
+		}
+
+		// the GetPrice query starts with a CTE
+		for name, operation := range map[string]string{"postgres COPY": "COPY", "postgres SELECT": "SELECT"} {
+			if got[name] != operation {
+				t.Errorf("want span: %q with operation: %q - got spans: %v", name, operation, got)
+			}
+		}
+		if _, ok := got["postgres WITH"]; ok {
+			t.Errorf("want no span named after a CTE - got spans: %v", got)
+		}
+	})
+}
+
+// TODO, GetBrand
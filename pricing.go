@@ -3,26 +3,122 @@ package pricing
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Price struct {
+	ID        int       // Price identifier assigned by the Repository when added, zero before. Read and written as PRICE_LIST in CSV files.
 	BrandID   int       // BRAND_ID: foreign key of the group chain (1 = EXAMPLE).
 	StartDate time.Time // START_DATE: date range in which the indicated price applies.
 	EndDate   time.Time // END_DATE: date range in which the indicated price applies.
 	ProductID int       // PRODUCT_ID: Product code identifier.
 	Priority  int       // PRIORITY: Price application disambiguator. If two prices coincide in a date range, the one with higher priority (higher numerical value) is applied.
-	Price     int       // PRICE: final selling price. Lowest unit for currency, e.g: cents // could be money.Money
-	Curr      string    // CURR: currency iso.
+	Price     Money     // PRICE & CURR: final selling price in the currency's minor unit, e.g: cents, and its ISO 4217 code.
 }
 
 type FinalPrice struct {
+	ID        int       // Price.ID of the applied price.
 	BrandID   int       // BRAND_ID: foreign key of the group chain (1 = EXAMPLE).
 	StartDate time.Time // START_DATE: date range in which the indicated price applies.
 	EndDate   time.Time // END_DATE: date range in which the indicated price applies.
-	ProductID int       // PRODUCT_ID: Product code identifier.
-	Price     int       // PRICE: final selling price. Lowest unit for currency, e.g: cents
-	Curr      string    // CURR: currency iso.
+	// EffectiveStartDate and EffectiveEndDate are the inclusive range during
+	// which this price keeps applying, narrower than START_DATE and END_DATE
+	// where higher priority prices override it. Safe to cache until
+	// EffectiveEndDate.
+	EffectiveStartDate time.Time
+	EffectiveEndDate   time.Time
+	ProductID          int   // PRODUCT_ID: Product code identifier.
+	Price              Money // PRICE & CURR: final selling price in the currency's minor unit, e.g: cents, and its ISO 4217 code.
+	// CompareAtPriceID and CompareAtPrice are the next lower priority price
+	// applying at the requested date, i.e: the price without the override of
+	// ID, e.g: to show "was 35.50 EUR, now 25.45 EUR". Zero if no other price
+	// applies. It may change within the effective window when lower priority
+	// prices start or end.
+	CompareAtPriceID int
+	CompareAtPrice   Money
+	// OriginalPrice is the price before the Discount of the promotion with
+	// PromotionID, or Price with a zero Discount and PromotionID if no
+	// promotion applies. Set by Service, Repositories only return base prices.
+	OriginalPrice Money
+	Discount      Money
+	PromotionID   int
+}
+
+// PriceSegment is an inclusive range of a price timeline during which the same
+// price, or no price, applies.
+type PriceSegment struct {
+	StartDate time.Time
+	EndDate   time.Time
+	PriceID   int   // ID of the applied Price, 0 if no price applies.
+	Price     Money // Applied price, zero if no price applies.
+}
+
+// PriorPrice is an inclusive range before a date during which the same price
+// applied, discounted by the promotion with PromotionID if any.
+type PriorPrice struct {
+	StartDate   time.Time
+	EndDate     time.Time
+	PriceID     int   // ID of the applied Price.
+	PromotionID int   // ID of the applied Promotion, 0 if none applied.
+	Price       Money // Applied price after any discount.
+}
+
+// PriceFilter selects prices to list, zero fields match any value.
+type PriceFilter struct {
+	BrandID   int
+	ProductID int
+}
+
+// PriceResult is the outcome of looking up a single product's price in a
+// batch, Err is set instead of Price when the lookup failed.
+type PriceResult struct {
+	ProductID int
+	Price     FinalPrice
+	Err       error
+}
+
+// Validate returns a *ValidationError for the first field of the Price that
+// can't be stored. It doesn't check whether the brand exists, Repositories are
+// responsible for that.
+func (p Price) Validate() error {
+	if p.BrandID <= 0 {
+		return &ValidationError{Field: "brand_id", Reason: "must be greater than 0"}
+	}
+
+	return p.validateExceptBrand()
+}
+
+// validateExceptBrand is Validate for prices whose brand is resolved later,
+// e.g: by name when seeding.
+func (p Price) validateExceptBrand() error {
+	switch {
+	case p.ProductID <= 0:
+		return &ValidationError{Field: "product_id", Reason: "must be greater than 0"}
+	case p.StartDate.IsZero():
+		return &ValidationError{Field: "start_date", Reason: "cannot be empty"}
+	case p.EndDate.IsZero():
+		return &ValidationError{Field: "end_date", Reason: "cannot be empty"}
+	case p.StartDate.After(p.EndDate):
+		return &ValidationError{Field: "end_date", Reason: "cannot be before start_date"}
+	case p.Price.Amount < 0:
+		return &ValidationError{Field: "price", Reason: "cannot be negative"}
+	}
+
+	if err := p.Price.Validate(); err != nil {
+		return &ValidationError{Field: "price", Reason: err.Error()}
+	}
+
+	return nil
 }
 
 type Brand struct {
@@ -33,47 +129,599 @@ type Brand struct {
 // Service contains a Repository and actions any business logic before/after
 // interacting with the Repository.
 type Service struct {
-	repo Repository
-	// timeoutSeconds int // query timeout for ctx, potentially per endpoint
-	// logger zerolog.Logger // log business logic, etc
+	repo    Repository
+	metrics *Metrics
+	logger  *slog.Logger // log changes to pricing data, etc
+	tracer  trace.Tracer
+	// readTimeout and writeTimeout bound each operation, zero for no limit.
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// ServiceOption configures a Service created by NewService.
+type ServiceOption func(*Service)
+
+// WithServiceMetrics counts price lookups with m.
+func WithServiceMetrics(m *Metrics) ServiceOption {
+	return func(srv *Service) { srv.metrics = m }
+}
+
+// WithServiceLogger sets the logger of the Service, defaults to slog.Default.
+func WithServiceLogger(logger *slog.Logger) ServiceOption {
+	return func(srv *Service) { srv.logger = logger }
+}
+
+// WithServiceTracerProvider creates spans for price lookups with tp, defaults
+// to no spans.
+func WithServiceTracerProvider(tp trace.TracerProvider) ServiceOption {
+	return func(srv *Service) { srv.tracer = tp.Tracer(tracerName) }
+}
+
+// WithServiceTimeouts limits how long each read and write of the repository
+// can take, zero for no limit. Expired operations return an error matching
+// context.DeadlineExceeded.
+func WithServiceTimeouts(read, write time.Duration) ServiceOption {
+	return func(srv *Service) {
+		srv.readTimeout = read
+		srv.writeTimeout = write
+	}
 }
 
 // NewService creates a new Service with the supplied repository ready for
 // reading and writing Price data.
-func NewService(repo Repository) *Service {
-	return &Service{
-		repo: repo,
+func NewService(repo Repository, opts ...ServiceOption) *Service {
+	srv := &Service{
+		repo:   repo,
+		logger: slog.Default(),
+		tracer: noop.NewTracerProvider().Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(srv)
 	}
+
+	return srv
 }
 
-func (srv *Service) AddBrand(ctx context.Context, name string) error {
-	// TODO: Any business logic common to Repositories
-	// TODO: Add any timeout to ctx
-	return srv.repo.AddBrand(ctx, name)
+// AddBrand inserts a new Brand into the backing storage repository and returns
+// it with the generated ID.
+func (srv *Service) AddBrand(ctx context.Context, name string) (Brand, error) {
+	if strings.TrimSpace(name) == "" {
+		return Brand{}, &ValidationError{Field: "name", Reason: "cannot be empty"}
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	brand, err := srv.repo.AddBrand(ctx, name)
+	if err != nil {
+		return Brand{}, err
+	}
+	srv.log(ctx).Info("brand added", slog.Int("brand_id", brand.ID), slog.String("name", brand.Name))
+
+	return brand, nil
 }
 
 func (srv *Service) GetBrand(ctx context.Context, name string) (Brand, error) {
 	// TODO: Any business logic common to Repositories
-	// TODO: Add any timeout to ctx
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
 	return srv.repo.GetBrand(ctx, name)
 }
 
-// AddPrice inserts a new Price into the backing storage repository.
-func (srv *Service) AddPrice(ctx context.Context, price Price) error {
-	// TODO: Any business logic common to Repositories
-	// TODO: Add any timeout to ctx
-	return srv.repo.AddPrice(ctx, price)
+// AddPrice validates and inserts a new Price into the backing storage
+// repository and returns it with the generated ID.
+func (srv *Service) AddPrice(ctx context.Context, price Price) (Price, error) {
+	if err := price.Validate(); err != nil {
+		return Price{}, err
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	price, err := srv.repo.AddPrice(ctx, price)
+	if err != nil {
+		return Price{}, err
+	}
+	srv.log(ctx).Info("price added", priceAttrs(price)...)
+
+	return price, nil
+}
+
+// AddPrices validates every price then inserts them all, or none of them, into
+// the backing storage repository and returns them with the generated IDs.
+// Invalid rows are reported together in a *BatchError.
+func (srv *Service) AddPrices(ctx context.Context, prices []Price) ([]Price, error) {
+	if len(prices) == 0 {
+		return nil, &ValidationError{Field: "prices", Reason: "cannot be empty"}
+	}
+
+	var rowErrs []RowError
+	for i, price := range prices {
+		if err := price.Validate(); err != nil {
+			rowErrs = append(rowErrs, RowError{Index: i, Err: err})
+		}
+	}
+	if len(rowErrs) > 0 {
+		return nil, &BatchError{Rows: rowErrs}
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	added, err := srv.repo.AddPrices(ctx, prices)
+	if err != nil {
+		return nil, err
+	}
+	srv.log(ctx).Info("prices added", slog.Int("count", len(added)))
+
+	return added, nil
+}
+
+// ImportPricesCSV reads a price list CSV file, see ReadPricesCSV, and adds
+// every price or none of them like AddPrices. Any PRICE_LIST IDs are ignored.
+// Failed rows are reported in a *BatchError with their line numbers.
+func (srv *Service) ImportPricesCSV(ctx context.Context, r io.Reader) ([]Price, error) {
+	prices, lines, err := readPricesCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := srv.AddPrices(ctx, prices)
+
+	var be *BatchError
+	if errors.As(err, &be) {
+		for i := range be.Rows {
+			be.Rows[i].Line = lines[be.Rows[i].Index]
+			be.Rows[i].Err = csvRowError(be.Rows[i].Err)
+		}
+	}
+
+	return added, err
+}
+
+// ListPrices returns the stored prices matching filter ordered by ID.
+func (srv *Service) ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error) {
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	return srv.repo.ListPrices(ctx, filter)
 }
 
 // GetPrice returns the final price to apply given the provided brand, product
-// and date. Price is an integer in the currencies lowest common demoninator,
-// For example, cents in USD, yen in JPY.
+// and date, discounted by the best Promotion applying at date if any. Price is
+// Money in the currency's minor unit, for example cents in USD, yen in JPY.
 func (srv *Service) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
+	ctx, span := srv.tracer.Start(ctx, "Service.GetPrice", trace.WithAttributes(
+		attribute.Int("brand_id", brandID),
+		attribute.Int("product_id", productID),
+		attribute.String("date", date.Format(time.RFC3339)),
+	))
+	defer span.End()
+
 	// TODO: Any business logic common to Repositories
-	// TODO: Add any timeout to ctx
-	return srv.repo.GetPrice(ctx, brandID, productID, date)
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	price, err := srv.getPrice(ctx, brandID, productID, date)
+	srv.metrics.observePriceLookup(brandID, err, srv.brandExists(ctx, brandID))
+	if err == nil {
+		span.SetAttributes(attribute.Int("price_id", price.ID), attribute.Int("promotion_id", price.PromotionID))
+	}
+	recordSpanError(span, err)
+	if err == nil {
+		srv.log(ctx).Debug("price resolved", slog.Int("brand_id", brandID), slog.Int("product_id", productID), slog.Time("date", date), slog.Int("price_id", price.ID), slog.Int("promotion_id", price.PromotionID))
+	}
+
+	return price, err
+}
+
+// getPrice resolves the base price at date then discounts it with the
+// promotions applying during its effective window.
+func (srv *Service) getPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
+	price, err := srv.repo.GetPrice(ctx, brandID, productID, date)
+	if err != nil {
+		return FinalPrice{}, err
+	}
+
+	promotions, err := srv.repo.GetPromotions(ctx, brandID, []int{productID}, price.EffectiveStartDate, price.EffectiveEndDate)
+	if err != nil {
+		return FinalPrice{}, err
+	}
+
+	return applyPromotions(price, promotions, date), nil
+}
+
+// maxBatchProducts limits the number of products per GetPrices call to bound
+// the size of queries and responses.
+const maxBatchProducts = 500
+
+// GetPrices returns the final price to apply for each of productIDs, in the
+// same order, given the provided brand and date, discounted like GetPrice.
+// Products without a price have an Err matching ErrNotFound rather than
+// failing the whole batch.
+func (srv *Service) GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) ([]PriceResult, error) {
+	switch {
+	case len(productIDs) == 0:
+		return nil, &ValidationError{Field: "product_ids", Reason: "cannot be empty"}
+	case len(productIDs) > maxBatchProducts:
+		return nil, &ValidationError{Field: "product_ids", Reason: fmt.Sprintf("cannot contain more than %d products", maxBatchProducts)}
+	}
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	prices, err := srv.repo.GetPrices(ctx, brandID, productIDs, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := srv.applyPromotions(ctx, brandID, prices, date); err != nil {
+		return nil, err
+	}
+
+	brandExists := srv.brandExists(ctx, brandID)
+	results := make([]PriceResult, 0, len(productIDs))
+	for _, productID := range productIDs {
+		result := PriceResult{ProductID: productID, Price: prices[productID]}
+		if _, ok := prices[productID]; !ok {
+			result.Err = errPriceNotFound(brandID, productID, date)
+		}
+
+		srv.metrics.observePriceLookup(brandID, result.Err, brandExists)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// brandExists returns a func reporting whether brandID exists, looked up once
+// on first call so lookups without misses don't query the Repository.
+func (srv *Service) brandExists(ctx context.Context, brandID int) func() bool {
+	return sync.OnceValue(func() bool {
+		_, err := srv.repo.GetBrandByID(ctx, brandID)
+		return err == nil
+	})
+}
+
+// applyPromotions discounts the base prices of a batch, keyed by product ID,
+// looking up the promotions of every product at once.
+func (srv *Service) applyPromotions(ctx context.Context, brandID int, prices map[int]FinalPrice, date time.Time) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	// the union of the effective windows covers every promotion that may
+	// apply, applyPromotions ignores the others.
+	var from, to time.Time
+	productIDs := make([]int, 0, len(prices))
+	for productID, price := range prices {
+		if len(productIDs) == 0 || price.EffectiveStartDate.Before(from) {
+			from = price.EffectiveStartDate
+		}
+		if len(productIDs) == 0 || price.EffectiveEndDate.After(to) {
+			to = price.EffectiveEndDate
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	promotions, err := srv.repo.GetPromotions(ctx, brandID, productIDs, from, to)
+	if err != nil {
+		return err
+	}
+
+	for productID, price := range prices {
+		prices[productID] = applyPromotions(price, promotions, date)
+	}
+
+	return nil
 }
 
-// Consider
-// func (srv *Service) DeleteBrand(...)
-// func (srv *Service) DeletePrice(...)
+// GetPriceTimeline returns the prices applying to the provided brand and
+// product between from and to, inclusive, as sorted contiguous segments
+// covering the whole range. Segments where no price applies have a PriceID of
+// 0.
+// Segments are base prices, promotions aren't applied.
+func (srv *Service) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error) {
+	if to.Before(from) {
+		return nil, &ValidationError{Field: "to", Reason: "cannot be before from"}
+	}
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	return srv.repo.GetPriceTimeline(ctx, brandID, productID, from, to)
+}
+
+// LowestPriorPriceWindow is how far back the EU Omnibus Directive requires
+// looking for the lowest price applied before a price reduction.
+const LowestPriorPriceWindow = 30 * 24 * time.Hour
+
+// GetLowestPriorPrice returns the lowest price applied to the provided brand
+// and product during window before date, excluding date itself, the earliest
+// if several tie. date is when the price reduction started, e.g: the
+// EffectiveStartDate of a FinalPrice, so the reduction itself isn't the lowest
+// prior price. Prices are resolved by priority like GetPriceTimeline then
+// discounted by the promotions applying at the time like GetPrice. Only prices
+// in the currency of the latest applied price are compared.
+// Returns ErrNotFound if no price applied during the window.
+func (srv *Service) GetLowestPriorPrice(ctx context.Context, brandID, productID int, date time.Time, window time.Duration) (PriorPrice, error) {
+	if window <= 0 {
+		return PriorPrice{}, &ValidationError{Field: "window", Reason: "must be greater than 0"}
+	}
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	from, to := date.Add(-window), date.Add(-time.Nanosecond)
+	segments, err := srv.repo.GetPriceTimeline(ctx, brandID, productID, from, to)
+	if err != nil {
+		return PriorPrice{}, err
+	}
+
+	promotions, err := srv.repo.GetPromotions(ctx, brandID, []int{productID}, from, to)
+	if err != nil {
+		return PriorPrice{}, err
+	}
+
+	var currency string
+	for i := len(segments) - 1; i >= 0 && currency == ""; i-- {
+		if segments[i].PriceID != 0 {
+			currency = segments[i].Price.Currency
+		}
+	}
+
+	var lowest PriorPrice
+	for _, seg := range segments {
+		if seg.PriceID == 0 || seg.Price.Currency != currency {
+			continue
+		}
+
+		// split the segment wherever the applying promotions change, each
+		// applyPromotions call narrows the effective window to one part.
+		for cursor := seg.StartDate; !cursor.After(seg.EndDate); {
+			price := applyPromotions(FinalPrice{
+				ID:                 seg.PriceID,
+				ProductID:          productID,
+				Price:              seg.Price,
+				EffectiveStartDate: seg.StartDate,
+				EffectiveEndDate:   seg.EndDate,
+			}, promotions, cursor)
+
+			if lowest.PriceID == 0 || price.Price.Amount < lowest.Price.Amount {
+				lowest = PriorPrice{
+					StartDate:   cursor,
+					EndDate:     price.EffectiveEndDate,
+					PriceID:     price.ID,
+					PromotionID: price.PromotionID,
+					Price:       price.Price,
+				}
+			}
+
+			cursor = price.EffectiveEndDate.Add(time.Nanosecond)
+		}
+	}
+
+	if lowest.PriceID == 0 {
+		return PriorPrice{}, errNoPriorPrice(brandID, productID, from, to)
+	}
+
+	return lowest, nil
+}
+
+// GetPriceByID returns the stored Price with id.
+func (srv *Service) GetPriceByID(ctx context.Context, id int) (Price, error) {
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	return srv.repo.GetPriceByID(ctx, id)
+}
+
+// UpdatePrice validates and replaces the stored Price with the same ID.
+func (srv *Service) UpdatePrice(ctx context.Context, price Price) (Price, error) {
+	if err := price.Validate(); err != nil {
+		return Price{}, err
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	price, err := srv.repo.UpdatePrice(ctx, price)
+	if err != nil {
+		return Price{}, err
+	}
+	srv.log(ctx).Info("price updated", priceAttrs(price)...)
+
+	return price, nil
+}
+
+// PricePatch holds the fields of a stored Price to replace, nil fields are
+// kept.
+type PricePatch struct {
+	BrandID   *int
+	StartDate *time.Time
+	EndDate   *time.Time
+	ProductID *int
+	Priority  *int
+	Price     *Money
+}
+
+// apply returns price with the fields of pp replaced, dates in UTC.
+func (pp PricePatch) apply(price Price) Price {
+	if pp.BrandID != nil {
+		price.BrandID = *pp.BrandID
+	}
+	if pp.StartDate != nil {
+		price.StartDate = pp.StartDate.UTC()
+	}
+	if pp.EndDate != nil {
+		price.EndDate = pp.EndDate.UTC()
+	}
+	if pp.ProductID != nil {
+		price.ProductID = *pp.ProductID
+	}
+	if pp.Priority != nil {
+		price.Priority = *pp.Priority
+	}
+	if pp.Price != nil {
+		price.Price = *pp.Price
+	}
+
+	return price
+}
+
+// PatchPrice replaces the fields of patch in the stored Price with id and
+// validates the result. The repository applies the patch atomically so
+// concurrent writes to other fields aren't lost.
+func (srv *Service) PatchPrice(ctx context.Context, id int, patch PricePatch) (Price, error) {
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	price, err := srv.repo.PatchPrice(ctx, id, func(price Price) (Price, error) {
+		price = patch.apply(price)
+		return price, price.Validate()
+	})
+	if err != nil {
+		return Price{}, err
+	}
+	srv.log(ctx).Info("price updated", priceAttrs(price)...)
+
+	return price, nil
+}
+
+// DeletePrice removes the stored Price with id.
+func (srv *Service) DeletePrice(ctx context.Context, id int) error {
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	if err := srv.repo.DeletePrice(ctx, id); err != nil {
+		return err
+	}
+	srv.log(ctx).Info("price deleted", slog.Int("price_id", id))
+
+	return nil
+}
+
+// RenameBrand changes the name of the Brand with id.
+func (srv *Service) RenameBrand(ctx context.Context, id int, name string) (Brand, error) {
+	if strings.TrimSpace(name) == "" {
+		return Brand{}, &ValidationError{Field: "name", Reason: "cannot be empty"}
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	brand, err := srv.repo.RenameBrand(ctx, id, name)
+	if err != nil {
+		return Brand{}, err
+	}
+	srv.log(ctx).Info("brand renamed", slog.Int("brand_id", brand.ID), slog.String("name", brand.Name))
+
+	return brand, nil
+}
+
+// AddPromotion validates and inserts a new Promotion into the backing storage
+// repository and returns it with the generated ID.
+func (srv *Service) AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error) {
+	if err := promotion.Validate(); err != nil {
+		return Promotion{}, err
+	}
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	promotion, err := srv.repo.AddPromotion(ctx, promotion)
+	if err != nil {
+		return Promotion{}, err
+	}
+	srv.log(ctx).Info("promotion added", promotionAttrs(promotion)...)
+
+	return promotion, nil
+}
+
+// GetPromotionByID returns the stored Promotion with id.
+func (srv *Service) GetPromotionByID(ctx context.Context, id int) (Promotion, error) {
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	return srv.repo.GetPromotionByID(ctx, id)
+}
+
+// DeletePromotion removes the stored Promotion with id.
+func (srv *Service) DeletePromotion(ctx context.Context, id int) error {
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	if err := srv.repo.DeletePromotion(ctx, id); err != nil {
+		return err
+	}
+	srv.log(ctx).Info("promotion deleted", slog.Int("promotion_id", id))
+
+	return nil
+}
+
+// Ping checks the backing storage repository can serve requests.
+func (srv *Service) Ping(ctx context.Context) error {
+	ctx, cancel := srv.readContext(ctx)
+	defer cancel()
+
+	return srv.repo.Ping(ctx)
+}
+
+// DeleteBrand removes the Brand with id. Brands referenced by prices can't be
+// deleted, delete the prices first.
+func (srv *Service) DeleteBrand(ctx context.Context, id int) error {
+	ctx, cancel := srv.writeContext(ctx)
+	defer cancel()
+
+	if err := srv.repo.DeleteBrand(ctx, id); err != nil {
+		return err
+	}
+	srv.log(ctx).Info("brand deleted", slog.Int("brand_id", id))
+
+	return nil
+}
+
+// readContext returns ctx limited by the read timeout, if any.
+func (srv *Service) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, srv.readTimeout)
+}
+
+// writeContext returns ctx limited by the write timeout, if any.
+func (srv *Service) writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, srv.writeTimeout)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// log returns the request scoped logger carried by ctx, if any, otherwise the
+// Service logger.
+func (srv *Service) log(ctx context.Context) *slog.Logger {
+	return loggerFromContext(ctx, srv.logger)
+}
+
+// priceAttrs describes price in log records.
+func priceAttrs(price Price) []any {
+	return []any{
+		slog.Int("price_id", price.ID),
+		slog.Int("brand_id", price.BrandID),
+		slog.Int("product_id", price.ProductID),
+		slog.Time("start_date", price.StartDate),
+		slog.Time("end_date", price.EndDate),
+		slog.Int("priority", price.Priority),
+		slog.String("price", price.Price.String()),
+	}
+}
+
+// promotionAttrs describes promotion in log records.
+func promotionAttrs(promotion Promotion) []any {
+	attrs := []any{
+		slog.Int("promotion_id", promotion.ID),
+		slog.Int("brand_id", promotion.BrandID),
+		slog.Any("product_ids", promotion.ProductIDs),
+		slog.Time("start_date", promotion.StartDate),
+		slog.Time("end_date", promotion.EndDate),
+	}
+	if promotion.PercentOff != 0 {
+		return append(attrs, slog.Int("percent_off", promotion.PercentOff))
+	}
+
+	return append(attrs, slog.String("amount_off", promotion.AmountOff.String()))
+}
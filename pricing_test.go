@@ -1,8 +1,14 @@
 package pricing_test
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/karlskewes/pricing"
 )
 
@@ -43,9 +49,985 @@ func initialPrices() ([]pricing.Price, error) {
 	}
 
 	return []pricing.Price{
-		{BrandID: 1, StartDate: t1.UTC(), EndDate: t2.UTC(), ProductID: 35455, Priority: 0, Price: 3550, Curr: "EUR"},
-		{BrandID: 1, StartDate: t3.UTC(), EndDate: t4.UTC(), ProductID: 35455, Priority: 1, Price: 2545, Curr: "EUR"},
-		{BrandID: 1, StartDate: t5.UTC(), EndDate: t6.UTC(), ProductID: 35455, Priority: 1, Price: 3050, Curr: "EUR"},
-		{BrandID: 1, StartDate: t7.UTC(), EndDate: t8.UTC(), ProductID: 35455, Priority: 1, Price: 3895, Curr: "EUR"},
+		{BrandID: 1, StartDate: t1.UTC(), EndDate: t2.UTC(), ProductID: 35455, Priority: 0, Price: pricing.Money{Amount: 3550, Currency: "EUR"}},
+		{BrandID: 1, StartDate: t3.UTC(), EndDate: t4.UTC(), ProductID: 35455, Priority: 1, Price: pricing.Money{Amount: 2545, Currency: "EUR"}},
+		{BrandID: 1, StartDate: t5.UTC(), EndDate: t6.UTC(), ProductID: 35455, Priority: 1, Price: pricing.Money{Amount: 3050, Currency: "EUR"}},
+		{BrandID: 1, StartDate: t7.UTC(), EndDate: t8.UTC(), ProductID: 35455, Priority: 1, Price: pricing.Money{Amount: 3895, Currency: "EUR"}},
 	}, nil
 }
+
+// repositoryTests are run against every Repository implementation, each with
+// a new empty repository.
+var repositoryTests = map[string]func(t *testing.T, repo pricing.Repository){
+	"errors":             testRepositoryErrors,
+	"money round trip":   testRepositoryMoneyRoundTrip,
+	"update delete":      testRepositoryUpdateDelete,
+	"patch price":        testRepositoryPatchPrice,
+	"conflicts":          testRepositoryConflicts,
+	"timeline":           testRepositoryTimeline,
+	"get prices":         testRepositoryGetPrices,
+	"add prices":         testRepositoryAddPrices,
+	"list prices":        testRepositoryListPrices,
+	"promotions":         testRepositoryPromotions,
+	"compare at":         testRepositoryCompareAt,
+	"lowest prior price": testRepositoryLowestPriorPrice,
+}
+
+// testRepositoryMoneyRoundTrip verifies Money is stored and read back without
+// losing its currency or precision.
+func testRepositoryMoneyRoundTrip(t *testing.T, repo pricing.Repository) {
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	startDate := time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC)
+
+	for productID, money := range []pricing.Money{
+		{Amount: 3550, Currency: "JPY"},
+		{Amount: -1250, Currency: "KWD"},
+		{Amount: 1 << 40, Currency: "IDR"},
+	} {
+		price := pricing.Price{BrandID: 1, StartDate: startDate, EndDate: startDate.Add(time.Hour), ProductID: productID + 1, Price: money}
+		if _, err := repo.AddPrice(ctx, price); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.GetPrice(ctx, 1, productID+1, startDate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(money, got.Price); diff != "" {
+			t.Errorf("repo.GetPrice(...) mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+// testRepositoryErrors verifies a Repository returns the sentinel errors for
+// common failures so every implementation behaves identically.
+func testRepositoryErrors(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := repo.GetBrand(ctx, "NOTEXIST")
+	if !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetBrand() want ErrNotFound - got: %v", err)
+	}
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.AddBrand(ctx, "EXAMPLE")
+	if !errors.Is(err, pricing.ErrConflict) {
+		t.Errorf("AddBrand() duplicate want ErrConflict - got: %v", err)
+	}
+
+	brand, err := repo.GetBrandByID(ctx, 1)
+	if err != nil || brand.Name != "EXAMPLE" {
+		t.Errorf("GetBrandByID() want EXAMPLE - got: %v, %v", brand, err)
+	}
+
+	_, err = repo.GetBrandByID(ctx, 2)
+	if !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetBrandByID() want ErrNotFound - got: %v", err)
+	}
+
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+
+	_, err = repo.AddPrice(ctx, pricing.Price{BrandID: 2, StartDate: date, EndDate: date, ProductID: 1, Price: pricing.Money{Amount: 100, Currency: "EUR"}})
+	if !errors.Is(err, pricing.ErrInvalidArgument) {
+		t.Errorf("AddPrice() missing brand want ErrInvalidArgument - got: %v", err)
+	}
+
+	_, err = repo.GetPrice(ctx, 1, 1, date)
+	if !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetPrice() want ErrNotFound - got: %v", err)
+	}
+}
+
+// testRepositoryUpdateDelete verifies a Repository updates and deletes prices
+// and brands by ID, refusing to delete brands that prices reference.
+func testRepositoryUpdateDelete(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	brand, err := repo.AddBrand(ctx, "EXAMPLE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := repo.AddBrand(ctx, "OTHER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+	price, err := repo.AddPrice(ctx, pricing.Price{BrandID: brand.ID, StartDate: date, EndDate: date.Add(time.Hour), ProductID: 1, Price: pricing.Money{Amount: 100, Currency: "EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.GetPriceByID(ctx, price.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(price, got); diff != "" {
+		t.Errorf("GetPriceByID() mismatch (-want +got):\n%s", diff)
+	}
+
+	// move the price to another brand and product
+	price.BrandID = other.ID
+	price.ProductID = 2
+	price.Price = pricing.Money{Amount: 200, Currency: "EUR"}
+	if _, err := repo.UpdatePrice(ctx, price); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetPrice(ctx, brand.ID, 1, date); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetPrice() old brand & product want ErrNotFound - got: %v", err)
+	}
+
+	fp, err := repo.GetPrice(ctx, other.ID, 2, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp.ID != price.ID || fp.Price != price.Price {
+		t.Errorf("GetPrice() want updated price: %v - got: %v", price, fp)
+	}
+
+	if _, err := repo.UpdatePrice(ctx, pricing.Price{ID: 999, BrandID: brand.ID, StartDate: date, EndDate: date, ProductID: 1}); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("UpdatePrice() missing price want ErrNotFound - got: %v", err)
+	}
+
+	if _, err := repo.UpdatePrice(ctx, pricing.Price{ID: price.ID, BrandID: 999, StartDate: date, EndDate: date, ProductID: 1}); !errors.Is(err, pricing.ErrInvalidArgument) {
+		t.Errorf("UpdatePrice() missing brand want ErrInvalidArgument - got: %v", err)
+	}
+
+	// brands
+	renamed, err := repo.RenameBrand(ctx, brand.ID, "RENAMED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.ID != brand.ID || renamed.Name != "RENAMED" {
+		t.Errorf("RenameBrand() unexpected brand: %v", renamed)
+	}
+
+	if _, err := repo.GetBrand(ctx, "EXAMPLE"); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetBrand() old name want ErrNotFound - got: %v", err)
+	}
+
+	if _, err := repo.RenameBrand(ctx, brand.ID, "OTHER"); !errors.Is(err, pricing.ErrConflict) {
+		t.Errorf("RenameBrand() taken name want ErrConflict - got: %v", err)
+	}
+
+	if _, err := repo.RenameBrand(ctx, 999, "NEW"); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("RenameBrand() missing brand want ErrNotFound - got: %v", err)
+	}
+
+	if err := repo.DeleteBrand(ctx, other.ID); !errors.Is(err, pricing.ErrConflict) {
+		t.Errorf("DeleteBrand() with prices want ErrConflict - got: %v", err)
+	}
+
+	if err := repo.DeletePrice(ctx, price.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeletePrice(ctx, price.ID); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("DeletePrice() twice want ErrNotFound - got: %v", err)
+	}
+
+	if _, err := repo.GetPriceByID(ctx, price.ID); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetPriceByID() deleted want ErrNotFound - got: %v", err)
+	}
+
+	if err := repo.DeleteBrand(ctx, other.ID); err != nil {
+		t.Errorf("DeleteBrand() without prices unexpected error: %v", err)
+	}
+
+	if err := repo.DeleteBrand(ctx, other.ID); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("DeleteBrand() twice want ErrNotFound - got: %v", err)
+	}
+
+	// IDs aren't reused after deletes
+	again, err := repo.AddBrand(ctx, "OTHER")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == other.ID {
+		t.Errorf("AddBrand() reused deleted id: %d", again.ID)
+	}
+}
+
+// testRepositoryPatchPrice verifies a Repository applies concurrent patches
+// of a price one at a time so none of them are lost.
+func testRepositoryPatchPrice(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	brand, err := repo.AddBrand(ctx, "EXAMPLE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+	price, err := repo.AddPrice(ctx, pricing.Price{BrandID: brand.ID, StartDate: date, EndDate: date.Add(time.Hour), ProductID: 1, Price: pricing.Money{Amount: 100, Currency: "EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const patches = 20
+	var wg sync.WaitGroup
+	for i := 0; i < patches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.PatchPrice(ctx, price.ID, func(p pricing.Price) (pricing.Price, error) {
+				p.Priority++
+				return p, nil
+			})
+			if err != nil {
+				t.Errorf("PatchPrice() unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := repo.GetPriceByID(ctx, price.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	price.Priority = patches
+	if diff := cmp.Diff(price, got); diff != "" {
+		t.Errorf("GetPriceByID() after patches mismatch (-want +got):\n%s", diff)
+	}
+
+	errPatch := errors.New("patch failed")
+	if _, err := repo.PatchPrice(ctx, price.ID, func(p pricing.Price) (pricing.Price, error) { return p, errPatch }); !errors.Is(err, errPatch) {
+		t.Errorf("PatchPrice() want patch error - got: %v", err)
+	}
+
+	if _, err := repo.PatchPrice(ctx, 999, func(p pricing.Price) (pricing.Price, error) { return p, nil }); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("PatchPrice() missing price want ErrNotFound - got: %v", err)
+	}
+}
+
+// testRepositoryConflicts verifies a Repository rejects prices overlapping
+// prices of the same brand, product and priority, listing the clashing IDs.
+func testRepositoryConflicts(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(hour int) time.Time {
+		return time.Date(2020, 06, 14, hour, 0, 0, 0, time.UTC)
+	}
+	eur := pricing.Money{Amount: 100, Currency: "EUR"}
+
+	a, err := repo.AddPrice(ctx, pricing.Price{BrandID: 1, StartDate: at(10), EndDate: at(12), ProductID: 1, Priority: 1, Price: eur})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Postgres sequences skip IDs of failed inserts so track the IDs of
+	// successfully added prices, wantAdded are indexes into it.
+	added := []int{a.ID}
+
+	testCases := []struct {
+		name      string
+		price     pricing.Price
+		wantAdded []int // nil for no conflict
+	}{
+		{"end touches start", pricing.Price{BrandID: 1, StartDate: at(12), EndDate: at(13), ProductID: 1, Priority: 1, Price: eur}, []int{0}},
+		{"inside", pricing.Price{BrandID: 1, StartDate: at(11), EndDate: at(11), ProductID: 1, Priority: 1, Price: eur}, []int{0}},
+		{"after end", pricing.Price{BrandID: 1, StartDate: at(12).Add(time.Microsecond), EndDate: at(14), ProductID: 1, Priority: 1, Price: eur}, nil},
+		{"different priority", pricing.Price{BrandID: 1, StartDate: at(10), EndDate: at(12), ProductID: 1, Priority: 2, Price: eur}, nil},
+		{"different product", pricing.Price{BrandID: 1, StartDate: at(10), EndDate: at(12), ProductID: 2, Priority: 1, Price: eur}, nil},
+		{"spans both", pricing.Price{BrandID: 1, StartDate: at(9), EndDate: at(15), ProductID: 1, Priority: 1, Price: eur}, []int{0, 1}},
+	}
+
+	for _, tt := range testCases {
+		price, err := repo.AddPrice(ctx, tt.price)
+		if tt.wantAdded == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			if price.ProductID == 1 && price.Priority == 1 {
+				added = append(added, price.ID)
+			}
+			continue
+		}
+
+		var pce *pricing.PriceConflictError
+		if !errors.As(err, &pce) || !errors.Is(err, pricing.ErrConflict) {
+			t.Errorf("%s: want PriceConflictError - got: %v", tt.name, err)
+			continue
+		}
+
+		wantIDs := make([]int, 0, len(tt.wantAdded))
+		for _, i := range tt.wantAdded {
+			wantIDs = append(wantIDs, added[i])
+		}
+
+		if diff := cmp.Diff(wantIDs, pce.IDs); diff != "" {
+			t.Errorf("%s: conflicting ids mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	// updating a price doesn't conflict with itself
+	if _, err := repo.UpdatePrice(ctx, a); err != nil {
+		t.Errorf("UpdatePrice() unchanged unexpected error: %v", err)
+	}
+
+	// but does with others
+	a.EndDate = at(13)
+	var pce *pricing.PriceConflictError
+	if _, err := repo.UpdatePrice(ctx, a); !errors.As(err, &pce) {
+		t.Errorf("UpdatePrice() overlapping want PriceConflictError - got: %v", err)
+	}
+}
+
+// testRepositoryTimeline verifies a Repository flattens the initial prices
+// into contiguous segments, including gaps, and clips them to the range.
+func testRepositoryTimeline(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, price := range prices {
+		if _, err := repo.AddPrice(ctx, price); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eur := func(amount int64) pricing.Money { return pricing.Money{Amount: amount, Currency: "EUR"} }
+	date := func(day, hour, min, sec, nsec int) time.Time {
+		return time.Date(2020, 06, day, hour, min, sec, nsec, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		productID int
+		from      time.Time
+		to        time.Time
+		want      []pricing.PriceSegment
+	}{
+		"priorities and gap": {
+			productID: 35455,
+			from:      date(13, 0, 0, 0, 0),
+			to:        date(16, 0, 0, 0, 0),
+			want: []pricing.PriceSegment{
+				{StartDate: date(13, 0, 0, 0, 0), EndDate: date(13, 23, 59, 59, 999999999)},
+				{StartDate: date(14, 0, 0, 0, 0), EndDate: date(14, 14, 59, 59, 999999999), PriceID: 1, Price: eur(3550)},
+				{StartDate: date(14, 15, 0, 0, 0), EndDate: date(14, 18, 30, 0, 0), PriceID: 2, Price: eur(2545)},
+				{StartDate: date(14, 18, 30, 0, 1), EndDate: date(14, 23, 59, 59, 999999999), PriceID: 1, Price: eur(3550)},
+				{StartDate: date(15, 0, 0, 0, 0), EndDate: date(15, 11, 0, 0, 0), PriceID: 3, Price: eur(3050)},
+				{StartDate: date(15, 11, 0, 0, 1), EndDate: date(15, 15, 59, 59, 999999999), PriceID: 1, Price: eur(3550)},
+				{StartDate: date(15, 16, 0, 0, 0), EndDate: date(16, 0, 0, 0, 0), PriceID: 4, Price: eur(3895)},
+			},
+		},
+		"within a single price": {
+			productID: 35455,
+			from:      date(14, 10, 0, 0, 0),
+			to:        date(14, 12, 0, 0, 0),
+			want: []pricing.PriceSegment{
+				{StartDate: date(14, 10, 0, 0, 0), EndDate: date(14, 12, 0, 0, 0), PriceID: 1, Price: eur(3550)},
+			},
+		},
+		"after every price": {
+			productID: 35455,
+			from:      time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+			to:        time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC),
+			want: []pricing.PriceSegment{
+				{StartDate: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), PriceID: 4, Price: eur(3895)},
+				{StartDate: time.Date(2020, 12, 31, 23, 59, 59, 1, time.UTC), EndDate: time.Date(2021, 01, 01, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		"product without prices": {
+			productID: 1,
+			from:      date(14, 0, 0, 0, 0),
+			to:        date(15, 0, 0, 0, 0),
+			want: []pricing.PriceSegment{
+				{StartDate: date(14, 0, 0, 0, 0), EndDate: date(15, 0, 0, 0, 0)},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := repo.GetPriceTimeline(ctx, 1, tt.productID, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("failed to get price timeline: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetPriceTimeline(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testRepositoryGetPrices verifies a Repository's batch lookup agrees with
+// GetPrice for each product and omits products without a price.
+func testRepositoryGetPrices(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same prices for a second product
+	for _, price := range prices {
+		price.ProductID = 35456
+		prices = append(prices, price)
+	}
+
+	for _, price := range prices {
+		if _, err := repo.AddPrice(ctx, price); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	productIDs := []int{35455, 35456, 1}
+
+	for _, date := range []time.Time{
+		time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC),
+		time.Date(2020, 06, 14, 16, 0, 0, 0, time.UTC),
+		time.Date(2020, 06, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 06, 15, 10, 0, 0, 0, time.UTC),
+	} {
+		got, err := repo.GetPrices(ctx, 1, productIDs, date)
+		if err != nil {
+			t.Fatalf("date: %s: failed to get prices: %v", date, err)
+		}
+
+		want := make(map[int]pricing.FinalPrice)
+		for _, productID := range productIDs {
+			price, err := repo.GetPrice(ctx, 1, productID, date)
+			if errors.Is(err, pricing.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want[productID] = price
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("date: %s: GetPrices(...) mismatch (-want +got):\n%s", date, diff)
+		}
+	}
+}
+
+// testRepositoryAddPrices verifies a Repository stores a batch atomically,
+// reporting every row whose brand doesn't exist or that conflicts.
+func testRepositoryAddPrices(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err := repo.AddPrice(ctx, prices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eur := pricing.Money{Amount: 100, Currency: "EUR"}
+	day := func(d int) time.Time { return time.Date(2020, 07, d, 0, 0, 0, 0, time.UTC) }
+
+	// batch row indexes that fail
+	testCases := map[string]struct {
+		batch    []pricing.Price
+		wantRows []int
+	}{
+		"brand does not exist": {
+			batch: []pricing.Price{
+				{BrandID: 1, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 1, Price: eur},
+				{BrandID: 2, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 1, Price: eur},
+			},
+			wantRows: []int{1},
+		},
+		"conflicts": {
+			batch: []pricing.Price{
+				{BrandID: 1, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 1, Price: eur},
+				{BrandID: 1, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 0, Price: eur}, // existing
+				{BrandID: 1, StartDate: day(3), EndDate: day(5), ProductID: 35455, Priority: 5, Price: eur},
+				{BrandID: 1, StartDate: day(5), EndDate: day(6), ProductID: 35455, Priority: 5, Price: eur}, // row 2
+			},
+			wantRows: []int{1, 2, 3},
+		},
+		"brands and conflicts": {
+			batch: []pricing.Price{
+				{BrandID: 2, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 1, Price: eur},
+				{BrandID: 1, StartDate: day(1), EndDate: day(2), ProductID: 35455, Priority: 0, Price: eur}, // existing
+			},
+			wantRows: []int{0, 1},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			_, err := repo.AddPrices(ctx, tt.batch)
+
+			var be *pricing.BatchError
+			if !errors.As(err, &be) {
+				t.Fatalf("AddPrices() want *BatchError - got: %v", err)
+			}
+
+			var gotRows []int
+			for _, row := range be.Rows {
+				gotRows = append(gotRows, row.Index)
+			}
+			if diff := cmp.Diff(tt.wantRows, gotRows); diff != "" {
+				t.Errorf("AddPrices() failed rows mismatch (-want +got):\n%s", diff)
+			}
+
+			// nothing from the batch is stored
+			got, err := repo.GetPrice(ctx, 1, 35455, day(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != existing.ID {
+				t.Errorf("GetPrice() want existing price: %d - got: %d", existing.ID, got.ID)
+			}
+		})
+	}
+
+	var pce *pricing.PriceConflictError
+	_, err = repo.AddPrices(ctx, testCases["conflicts"].batch)
+	if !errors.As(err, &pce) || !cmp.Equal(pce.IDs, []int{existing.ID}) {
+		t.Errorf("AddPrices() want *PriceConflictError with ids: [%d] - got: %v", existing.ID, err)
+	}
+
+	added, err := repo.AddPrices(ctx, prices[1:])
+	if err != nil {
+		t.Fatalf("failed to add prices: %v", err)
+	}
+
+	for i, price := range added {
+		want := prices[1+i]
+		want.ID = price.ID
+
+		got, err := repo.GetPriceByID(ctx, price.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("GetPriceByID(%d) mismatch (-want +got):\n%s", price.ID, diff)
+		}
+	}
+}
+
+// testRepositoryListPrices verifies a Repository lists the prices matching a
+// filter ordered by ID.
+func testRepositoryListPrices(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices[2].ProductID = 35456
+
+	var added []pricing.Price
+	for _, price := range prices {
+		price, err := repo.AddPrice(ctx, price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, price)
+	}
+
+	testCases := map[string]struct {
+		filter pricing.PriceFilter
+		want   []pricing.Price
+	}{
+		"all":        {pricing.PriceFilter{}, added},
+		"brand":      {pricing.PriceFilter{BrandID: 1}, added},
+		"product":    {pricing.PriceFilter{ProductID: 35456}, added[2:3]},
+		"no matches": {pricing.PriceFilter{BrandID: 2}, []pricing.Price{}},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := repo.ListPrices(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ListPrices(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testRepositoryPromotions verifies a Repository stores promotions and finds
+// them by product and date range, and that Service.GetPrice applies the best
+// promotion on top of the seeded example prices.
+func testRepositoryPromotions(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	brand, err := repo.AddBrand(ctx, "EXAMPLE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AddPrices(ctx, prices); err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(hour, min int) time.Time { return time.Date(2020, 06, 14, hour, min, 0, 0, time.UTC) }
+
+	_, err = repo.AddPromotion(ctx, pricing.Promotion{BrandID: 99, ProductIDs: []int{35455}, StartDate: at(10, 0), EndDate: at(12, 0), PercentOff: 10})
+	if !errors.Is(err, pricing.ErrInvalidArgument) {
+		t.Errorf("AddPromotion() missing brand want ErrInvalidArgument - got: %v", err)
+	}
+
+	var added []pricing.Promotion
+	for _, promotion := range []pricing.Promotion{
+		{BrandID: brand.ID, ProductIDs: []int{35455, 1}, StartDate: at(10, 0), EndDate: at(12, 0), PercentOff: 10},
+		{BrandID: brand.ID, ProductIDs: []int{35455}, StartDate: at(11, 0), EndDate: at(20, 0), AmountOff: pricing.Money{Amount: 500, Currency: "EUR"}},
+		// other currencies never apply
+		{BrandID: brand.ID, ProductIDs: []int{35455}, StartDate: at(0, 0), EndDate: at(23, 0), AmountOff: pricing.Money{Amount: 3000, Currency: "USD"}},
+	} {
+		promotion, err := repo.AddPromotion(ctx, promotion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, promotion)
+	}
+	percent, amount, usd := added[0], added[1], added[2]
+
+	got, err := repo.GetPromotionByID(ctx, percent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(percent, got); diff != "" {
+		t.Errorf("GetPromotionByID() mismatch (-want +got):\n%s", diff)
+	}
+
+	promotions, err := repo.GetPromotions(ctx, brand.ID, []int{35455}, at(13, 0), at(14, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]pricing.Promotion{amount, usd}, promotions); diff != "" {
+		t.Errorf("GetPromotions() mismatch (-want +got):\n%s", diff)
+	}
+
+	promotions, err = repo.GetPromotions(ctx, brand.ID, []int{1, 2}, at(0, 0), at(23, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]pricing.Promotion{percent}, promotions); diff != "" {
+		t.Errorf("GetPromotions() mismatch (-want +got):\n%s", diff)
+	}
+
+	svc := pricing.NewService(repo)
+	eur := func(amount int64) pricing.Money { return pricing.Money{Amount: amount, Currency: "EUR"} }
+
+	testCases := map[string]struct {
+		date time.Time
+		want pricing.FinalPrice
+	}{
+		"before promotions": {
+			date: at(9, 0),
+			want: pricing.FinalPrice{ID: 1, Price: eur(3550), OriginalPrice: eur(3550), Discount: eur(0), EffectiveStartDate: at(0, 0), EffectiveEndDate: at(10, 0).Add(-time.Nanosecond)},
+		},
+		"percent off": {
+			date: at(10, 30),
+			want: pricing.FinalPrice{ID: 1, Price: eur(3195), OriginalPrice: eur(3550), Discount: eur(355), PromotionID: percent.ID, EffectiveStartDate: at(10, 0), EffectiveEndDate: at(11, 0).Add(-time.Nanosecond)},
+		},
+		"largest discount wins": {
+			date: at(11, 30),
+			want: pricing.FinalPrice{ID: 1, Price: eur(3050), OriginalPrice: eur(3550), Discount: eur(500), PromotionID: amount.ID, EffectiveStartDate: at(11, 0), EffectiveEndDate: at(12, 0)},
+		},
+		"higher priority base price": {
+			date: at(16, 0),
+			want: pricing.FinalPrice{ID: 2, Price: eur(2045), CompareAtPriceID: 1, CompareAtPrice: eur(3550), OriginalPrice: eur(2545), Discount: eur(500), PromotionID: amount.ID, EffectiveStartDate: at(15, 0), EffectiveEndDate: at(18, 30)},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := svc.GetPrice(ctx, brand.ID, 35455, tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opt := cmpopts.IgnoreFields(pricing.FinalPrice{}, "BrandID", "ProductID", "StartDate", "EndDate")
+			if diff := cmp.Diff(tt.want, got, opt); diff != "" {
+				t.Errorf("GetPrice() mismatch (-want +got):\n%s", diff)
+			}
+
+			results, err := svc.GetPrices(ctx, brand.ID, []int{35455, 1}, tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, results[0].Price); diff != "" {
+				t.Errorf("GetPrices() mismatch with GetPrice() (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// promotions keep brands from being deleted like prices
+	other, err := repo.AddBrand(ctx, "OTHER")
+	if err != nil {
+		t.Fatal(err)
+	}
+	promotion, err := repo.AddPromotion(ctx, pricing.Promotion{BrandID: other.ID, ProductIDs: []int{1}, StartDate: at(0, 0), EndDate: at(1, 0), PercentOff: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteBrand(ctx, other.ID); !errors.Is(err, pricing.ErrConflict) {
+		t.Errorf("DeleteBrand() with promotions want ErrConflict - got: %v", err)
+	}
+
+	if err := repo.DeletePromotion(ctx, promotion.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeletePromotion(ctx, promotion.ID); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("DeletePromotion() deleted want ErrNotFound - got: %v", err)
+	}
+	if _, err := repo.GetPromotionByID(ctx, promotion.ID); !errors.Is(err, pricing.ErrNotFound) {
+		t.Errorf("GetPromotionByID() deleted want ErrNotFound - got: %v", err)
+	}
+
+	if err := repo.DeleteBrand(ctx, other.ID); err != nil {
+		t.Errorf("DeleteBrand() without promotions: %v", err)
+	}
+}
+
+// testRepositoryCompareAt verifies a Repository compares the winning price at
+// the next lower priority price applying at the same instant, without the
+// lower priority prices changing the effective window.
+func testRepositoryCompareAt(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(hour, min int) time.Time { return time.Date(2020, 06, 14, hour, min, 0, 0, time.UTC) }
+
+	// a flash sale overriding price 2
+	prices = append(prices, pricing.Price{BrandID: 1, StartDate: at(16, 0), EndDate: at(17, 0), ProductID: 35455, Priority: 2, Price: pricing.Money{Amount: 2000, Currency: "EUR"}})
+	if _, err := repo.AddPrices(ctx, prices); err != nil {
+		t.Fatal(err)
+	}
+
+	eur := func(amount int64) pricing.Money { return pricing.Money{Amount: amount, Currency: "EUR"} }
+
+	testCases := map[string]struct {
+		date time.Time
+		want pricing.FinalPrice
+	}{
+		"no lower priority price": {
+			date: at(10, 0),
+			want: pricing.FinalPrice{ID: 1, Price: eur(3550), EffectiveStartDate: at(0, 0), EffectiveEndDate: at(15, 0).Add(-time.Nanosecond)},
+		},
+		"next lower priority": {
+			date: at(16, 30),
+			want: pricing.FinalPrice{ID: 5, Price: eur(2000), CompareAtPriceID: 2, CompareAtPrice: eur(2545), EffectiveStartDate: at(16, 0), EffectiveEndDate: at(17, 0)},
+		},
+		"effective window spans lower priority changes": {
+			date: at(15, 30),
+			want: pricing.FinalPrice{ID: 2, Price: eur(2545), CompareAtPriceID: 1, CompareAtPrice: eur(3550), EffectiveStartDate: at(15, 0), EffectiveEndDate: at(16, 0).Add(-time.Nanosecond)},
+		},
+		"after override": {
+			date: at(17, 30),
+			want: pricing.FinalPrice{ID: 2, Price: eur(2545), CompareAtPriceID: 1, CompareAtPrice: eur(3550), EffectiveStartDate: at(17, 0).Add(time.Nanosecond), EffectiveEndDate: at(18, 30)},
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := repo.GetPrice(ctx, 1, 35455, tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opt := cmpopts.IgnoreFields(pricing.FinalPrice{}, "BrandID", "ProductID", "StartDate", "EndDate")
+			if diff := cmp.Diff(tt.want, got, opt); diff != "" {
+				t.Errorf("GetPrice() mismatch (-want +got):\n%s", diff)
+			}
+
+			batch, err := repo.GetPrices(ctx, 1, []int{35455}, tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, batch[35455]); diff != "" {
+				t.Errorf("GetPrices() mismatch with GetPrice() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testRepositoryLowestPriorPrice verifies the Service finds the lowest price,
+// including promotions, applied before a date from the initial prices of a
+// Repository.
+func testRepositoryLowestPriorPrice(t *testing.T, repo pricing.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if _, err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// product 2 changed currency, only the latest is compared
+	prices = append(prices,
+		pricing.Price{BrandID: 1, StartDate: time.Date(2020, 06, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2020, 06, 9, 23, 59, 59, 0, time.UTC), ProductID: 2, Price: pricing.Money{Amount: 1000, Currency: "USD"}},
+		pricing.Price{BrandID: 1, StartDate: time.Date(2020, 06, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2020, 06, 20, 23, 59, 59, 0, time.UTC), ProductID: 2, Price: pricing.Money{Amount: 2000, Currency: "EUR"}},
+	)
+	if _, err := repo.AddPrices(ctx, prices); err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(day, hour, min int) time.Time { return time.Date(2020, 06, day, hour, min, 0, 0, time.UTC) }
+
+	// 20% off price 2 for an hour is the lowest price customers saw
+	promotion, err := repo.AddPromotion(ctx, pricing.Promotion{BrandID: 1, ProductIDs: []int{35455}, StartDate: at(14, 16, 0), EndDate: at(14, 17, 0), PercentOff: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := pricing.NewService(repo)
+	eur := func(amount int64) pricing.Money { return pricing.Money{Amount: amount, Currency: "EUR"} }
+
+	testCases := map[string]struct {
+		productID int
+		date      time.Time
+		window    time.Duration
+		want      pricing.PriorPrice
+		wantErr   error
+	}{
+		"no prior price": {
+			productID: 35455,
+			date:      at(14, 0, 0),
+			window:    pricing.LowestPriorPriceWindow,
+			wantErr:   pricing.ErrNotFound,
+		},
+		"price reduction": {
+			productID: 35455,
+			date:      at(14, 15, 0),
+			window:    pricing.LowestPriorPriceWindow,
+			want:      pricing.PriorPrice{StartDate: at(14, 0, 0), EndDate: at(14, 15, 0).Add(-time.Nanosecond), PriceID: 1, Price: eur(3550)},
+		},
+		"promoted prior price": {
+			productID: 35455,
+			date:      at(16, 21, 0),
+			window:    pricing.LowestPriorPriceWindow,
+			want:      pricing.PriorPrice{StartDate: at(14, 16, 0), EndDate: at(14, 17, 0), PriceID: 2, PromotionID: promotion.ID, Price: eur(2036)},
+		},
+		"promotion clipped to window": {
+			productID: 35455,
+			date:      at(14, 16, 30),
+			window:    10 * time.Minute,
+			want:      pricing.PriorPrice{StartDate: at(14, 16, 20), EndDate: at(14, 16, 30).Add(-time.Nanosecond), PriceID: 2, PromotionID: promotion.ID, Price: eur(2036)},
+		},
+		"before promotion": {
+			productID: 35455,
+			date:      at(14, 16, 0),
+			window:    pricing.LowestPriorPriceWindow,
+			want:      pricing.PriorPrice{StartDate: at(14, 15, 0), EndDate: at(14, 16, 0).Add(-time.Nanosecond), PriceID: 2, Price: eur(2545)},
+		},
+		"segments clipped to window": {
+			productID: 35455,
+			date:      at(15, 12, 0),
+			window:    time.Hour,
+			want:      pricing.PriorPrice{StartDate: at(15, 11, 0), EndDate: at(15, 11, 0), PriceID: 3, Price: eur(3050)},
+		},
+		"prices ended before window": {
+			productID: 35455,
+			date:      time.Date(2021, 03, 1, 0, 0, 0, 0, time.UTC),
+			window:    pricing.LowestPriorPriceWindow,
+			wantErr:   pricing.ErrNotFound,
+		},
+		"latest currency": {
+			productID: 2,
+			date:      at(21, 0, 0),
+			window:    pricing.LowestPriorPriceWindow,
+			want:      pricing.PriorPrice{StartDate: at(10, 0, 0), EndDate: time.Date(2020, 06, 20, 23, 59, 59, 0, time.UTC), PriceID: 6, Price: eur(2000)},
+		},
+		"invalid window": {
+			productID: 35455,
+			date:      at(16, 21, 0),
+			wantErr:   pricing.ErrInvalidArgument,
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := svc.GetLowestPriorPrice(ctx, 1, tt.productID, tt.date, tt.window)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetLowestPriorPrice() want error: %v - got: %v", tt.wantErr, err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetLowestPriorPrice() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Stable machine readable error codes returned in Problem responses. Clients
+// should switch on these rather than the human readable title or detail.
+const (
+	CodeMissingParameter = "missing_parameter" // required query parameter is empty
+	CodeInvalidParameter = "invalid_parameter" // parameter or body field has an invalid value
+	CodeInvalidBody      = "invalid_body"      // request body isn't valid JSON for the endpoint
+	CodeNotFound         = "not_found"         // route or resource doesn't exist
+	CodeConflict         = "conflict"          // write clashes with existing data
+	CodeUnavailable      = "unavailable"       // backend can't be reached, retry later
+	CodeTimeout          = "timeout"           // backend didn't respond in time, retry later
+	CodeInternal         = "internal"          // unexpected server side failure
+)
+
+// statusClientClosedRequest is the non-standard status, popularised by nginx,
+// recorded when the client goes away before the response is written.
+const statusClientClosedRequest = 499
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response body, extended with the
+// machine readable error code, the offending parameter and the string_id
+// supplied by the client.
+// See: https://www.rfc-editor.org/rfc/rfc7807
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Param    string `json:"param,omitempty"`
+	StringID string `json:"string_id,omitempty"`
+	// ConflictingIDs are the IDs of existing prices a write clashes with.
+	ConflictingIDs []int `json:"conflicting_ids,omitempty"`
+	// Errors are the problems of each failed row of a batch write.
+	Errors []RowProblem `json:"errors,omitempty"`
+}
+
+// RowProblem describes why a single row of a batch write failed.
+type RowProblem struct {
+	Index          int    `json:"index"`
+	Line           int    `json:"line,omitempty"`
+	Code           string `json:"code"`
+	Param          string `json:"param,omitempty"`
+	Detail         string `json:"detail,omitempty"`
+	ConflictingIDs []int  `json:"conflicting_ids,omitempty"`
+}
+
+// writeProblem writes a problem details response for the request.
+func writeProblem(w http.ResponseWriter, req *http.Request, status int, code, param, detail string) {
+	writeProblemDetails(w, req, Problem{
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Param:  param,
+	}, req.URL.Query().Get("string_id"))
+}
+
+// problemFor returns p with the fields common to every problem details
+// response for the request filled in, echoing the string_id supplied by the
+// client in the query or body.
+func problemFor(req *http.Request, p Problem, stringID string) Problem {
+	p.Type = "about:blank" // title is the HTTP status text per RFC 7807 section 4.2
+	p.Title = http.StatusText(p.Status)
+	p.Instance = req.URL.Path
+	p.StringID = stringID
+
+	return p
+}
+
+// writeProblemDetails writes p for the request, see problemFor.
+func writeProblemDetails(w http.ResponseWriter, req *http.Request, p Problem, stringID string) {
+	p = problemFor(req, p, stringID)
+
+	res, err := json.Marshal(p)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", problemContentType)
+	w.WriteHeader(p.Status)
+
+	_, err = w.Write(res)
+	if err != nil {
+		requestLogger(req).Warn("failed to write response", slog.Any("error", err))
+	}
+}
+
+// writeError writes a problem details response for an error returned by the
+// Service, mapping the Repository sentinel errors to HTTP status codes.
+// Details of unexpected errors aren't exposed to clients.
+// Server side failures are logged instead. Requests canceled by the client
+// aren't a failure, they're recorded as 499 without a body nobody would read.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	writeErrorWithStringID(w, req, err, req.URL.Query().Get("string_id"))
+}
+
+// writeErrorWithStringID is writeError for requests supplying their string_id
+// in the body rather than the query.
+func writeErrorWithStringID(w http.ResponseWriter, req *http.Request, err error, stringID string) {
+	if errors.Is(err, context.Canceled) && req.Context().Err() != nil {
+		requestLogger(req).Debug("request canceled by client", slog.Any("error", err))
+		w.WriteHeader(statusClientClosedRequest)
+
+		return
+	}
+
+	p := errorProblem(err)
+	if p.Status >= http.StatusInternalServerError {
+		requestLogger(req).Error("request failed", slog.Any("error", err))
+	}
+
+	writeProblemDetails(w, req, p, stringID)
+}
+
+// errorProblem returns the problem details, without the fields common to
+// every response, describing an error returned by the Service.
+func errorProblem(err error) Problem {
+	var be *BatchError
+	var ve *ValidationError
+	var pce *PriceConflictError
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.As(err, &be):
+		return batchProblem(be) // before ValidationError as it wraps the row errors
+	case errors.As(err, &ve):
+		return Problem{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Param: ve.Field, Detail: ve.Reason}
+	case errors.As(err, &pce):
+		return Problem{Status: http.StatusConflict, Code: CodeConflict, Detail: err.Error(), ConflictingIDs: pce.IDs}
+	case errors.As(err, &mbe):
+		return Problem{Status: http.StatusRequestEntityTooLarge, Code: CodeInvalidBody, Detail: fmt.Sprintf("request body exceeds %d bytes", mbe.Limit)}
+	case errors.Is(err, ErrInvalidArgument):
+		return Problem{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Detail: err.Error()}
+	case errors.Is(err, ErrNotFound):
+		return Problem{Status: http.StatusNotFound, Code: CodeNotFound, Detail: err.Error()}
+	case errors.Is(err, ErrConflict):
+		return Problem{Status: http.StatusConflict, Code: CodeConflict, Detail: err.Error()}
+	case errors.Is(err, ErrUnavailable):
+		return Problem{Status: http.StatusServiceUnavailable, Code: CodeUnavailable, Detail: "backend unavailable, retry later"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Problem{Status: http.StatusGatewayTimeout, Code: CodeTimeout, Detail: "backend timed out, retry later"}
+	default:
+		return Problem{Status: http.StatusInternalServerError, Code: CodeInternal}
+	}
+}
+
+// batchProblem returns the problem details of a failed batch write, listing
+// every failed row. Invalid rows take precedence over conflicts for the status.
+func batchProblem(be *BatchError) Problem {
+	p := Problem{Status: http.StatusConflict, Code: CodeConflict, Detail: be.Error()}
+	if errors.Is(be, ErrInvalidArgument) {
+		p.Status = http.StatusBadRequest
+		p.Code = CodeInvalidParameter
+	}
+
+	p.Errors = make([]RowProblem, 0, len(be.Rows))
+	for _, row := range be.Rows {
+		rp := errorProblem(row.Err)
+		p.Errors = append(p.Errors, RowProblem{
+			Index:          row.Index,
+			Line:           row.Line,
+			Code:           rp.Code,
+			Param:          rp.Param,
+			Detail:         rp.Detail,
+			ConflictingIDs: rp.ConflictingIDs,
+		})
+	}
+
+	return p
+}
+
+// writeJSON writes v as a JSON response body with the provided status.
+func writeJSON(w http.ResponseWriter, req *http.Request, status int, v any) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		writeProblem(w, req, http.StatusInternalServerError, CodeInternal, "", "failed to encode response")
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(res)
+	if err != nil {
+		requestLogger(req).Warn("failed to write response", slog.Any("error", err))
+	}
+}
+
+// Request body limits so clients can't exhaust memory with unbounded bodies.
+// JSON bodies fit a maximum size batch, CSV price lists are larger as they're
+// imported in one request.
+const (
+	maxJSONBodyBytes = 1 << 20
+	maxCSVBodyBytes  = 32 << 20
+)
+
+// decodeJSON decodes the request body into v, writing a problem details
+// response and returning false if the body is invalid or exceeds
+// maxJSONBodyBytes.
+func decodeJSON(w http.ResponseWriter, req *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxJSONBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeError(w, req, err)
+			return false
+		}
+		writeProblem(w, req, http.StatusBadRequest, CodeInvalidBody, "", err.Error())
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,155 @@
+package pricing
+
+import (
+	"time"
+)
+
+// Promotion discounts the base price of a brand's products during a date
+// range. Exactly one of PercentOff and AmountOff is set. Where several
+// promotions apply the largest discount wins, they aren't combined.
+type Promotion struct {
+	ID         int       // Identifier of the promotion, generated by the Repository.
+	BrandID    int       // Brand of the discounted products.
+	ProductIDs []int     // Products the promotion applies to.
+	StartDate  time.Time // Date range in which the promotion applies, inclusive like Price.
+	EndDate    time.Time
+	PercentOff int   // Percentage off the base price, 1 to 100.
+	AmountOff  Money // Fixed amount off the base price, only applies to prices in the same currency.
+}
+
+// Validate returns a *ValidationError for the first field of the Promotion
+// that can't be stored. It doesn't check whether the brand exists,
+// Repositories are responsible for that.
+func (p Promotion) Validate() error {
+	switch {
+	case p.BrandID <= 0:
+		return &ValidationError{Field: "brand_id", Reason: "must be greater than 0"}
+	case len(p.ProductIDs) == 0:
+		return &ValidationError{Field: "product_ids", Reason: "cannot be empty"}
+	case p.StartDate.IsZero():
+		return &ValidationError{Field: "start_date", Reason: "cannot be empty"}
+	case p.EndDate.IsZero():
+		return &ValidationError{Field: "end_date", Reason: "cannot be empty"}
+	case p.StartDate.After(p.EndDate):
+		return &ValidationError{Field: "end_date", Reason: "cannot be before start_date"}
+	case p.PercentOff == 0 && p.AmountOff == (Money{}):
+		return &ValidationError{Field: "percent_off", Reason: "is required unless amount_off is set"}
+	case p.PercentOff != 0 && p.AmountOff != (Money{}):
+		return &ValidationError{Field: "amount_off", Reason: "cannot be combined with percent_off"}
+	case p.PercentOff < 0 || p.PercentOff > 100:
+		return &ValidationError{Field: "percent_off", Reason: "must be between 1 and 100"}
+	}
+
+	seen := make(map[int]bool, len(p.ProductIDs))
+	for _, id := range p.ProductIDs {
+		switch {
+		case id <= 0:
+			return &ValidationError{Field: "product_ids", Reason: "must be greater than 0"}
+		case seen[id]:
+			return &ValidationError{Field: "product_ids", Reason: "cannot contain duplicates"}
+		}
+		seen[id] = true
+	}
+
+	if p.PercentOff != 0 {
+		return nil
+	}
+
+	if p.AmountOff.Amount <= 0 {
+		return &ValidationError{Field: "amount_off", Reason: "must be greater than 0"}
+	}
+	if err := p.AmountOff.Validate(); err != nil {
+		return &ValidationError{Field: "amount_off", Reason: err.Error()}
+	}
+
+	return nil
+}
+
+// includes reports whether the promotion lists productID.
+func (p Promotion) includes(productID int) bool {
+	for _, id := range p.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discount returns how much the promotion takes off price, never more than
+// price itself. Percentages are rounded down to the currency's minor unit so
+// customers are never charged less than advertised. Fixed amounts in another
+// currency don't apply.
+func (p Promotion) discount(price Money) (Money, bool) {
+	off := Money{Currency: price.Currency}
+
+	switch {
+	case p.PercentOff > 0:
+		// split the multiplication so large amounts don't overflow
+		percent := int64(p.PercentOff)
+		off.Amount = price.Amount/100*percent + price.Amount%100*percent/100
+	case p.AmountOff.Currency == price.Currency:
+		off.Amount = p.AmountOff.Amount
+	default:
+		return Money{}, false
+	}
+
+	if off.Amount > price.Amount {
+		off.Amount = price.Amount
+	}
+
+	return off, true
+}
+
+// applyPromotions discounts the base price with the best of promotions
+// applying to its product at date. The effective window is narrowed to the
+// range during which the same promotions apply, so the discounted price is
+// still safe to cache until EffectiveEndDate. Promotions of other products or
+// outside the window are ignored.
+func applyPromotions(price FinalPrice, promotions []Promotion, date time.Time) FinalPrice {
+	price.OriginalPrice = price.Price
+	price.Discount = Money{Currency: price.Price.Currency}
+	price.PromotionID = 0
+
+	for _, promo := range promotions {
+		if !promo.includes(price.ProductID) {
+			continue
+		}
+
+		switch {
+		case promo.EndDate.Before(date):
+			if end := promo.EndDate.Add(time.Nanosecond); end.After(price.EffectiveStartDate) {
+				price.EffectiveStartDate = end
+			}
+			continue
+		case promo.StartDate.After(date):
+			if start := promo.StartDate.Add(-time.Nanosecond); start.Before(price.EffectiveEndDate) {
+				price.EffectiveEndDate = start
+			}
+			continue
+		}
+
+		if promo.StartDate.After(price.EffectiveStartDate) {
+			price.EffectiveStartDate = promo.StartDate
+		}
+		if promo.EndDate.Before(price.EffectiveEndDate) {
+			price.EffectiveEndDate = promo.EndDate
+		}
+
+		off, ok := promo.discount(price.OriginalPrice)
+		if !ok {
+			continue
+		}
+
+		// largest discount wins, ties go to the earliest added promotion
+		if price.PromotionID == 0 || off.Amount > price.Discount.Amount ||
+			(off.Amount == price.Discount.Amount && promo.ID < price.PromotionID) {
+			price.Discount = off
+			price.PromotionID = promo.ID
+		}
+	}
+
+	price.Price.Amount = price.OriginalPrice.Amount - price.Discount.Amount
+
+	return price
+}
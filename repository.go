@@ -2,19 +2,101 @@ package pricing
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Repository implements persisting and reading pricing data from a backend.
 type Repository interface {
-	AddPrice(ctx context.Context, price Price) error
+	// AddPrice stores the price with a generated ID, ignoring any provided
+	// ID, and returns a *ValidationError if the brand doesn't exist.
+	AddPrice(ctx context.Context, price Price) (Price, error)
+	// AddPrices stores every price with generated IDs, or none of them, and
+	// returns a *BatchError listing the rows whose brand doesn't exist or
+	// that conflict.
+	AddPrices(ctx context.Context, prices []Price) ([]Price, error)
 	GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error)
-	AddBrand(ctx context.Context, name string) error
+	// GetPrices returns the price applying to each of productIDs at date,
+	// keyed by product ID. Products without a price are omitted.
+	GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) (map[int]FinalPrice, error)
+	// GetPriceTimeline returns segments covering from to to, inclusive, with
+	// gaps where no price applies as segments with a PriceID of 0.
+	GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error)
+	// ListPrices returns the prices matching filter ordered by ID.
+	ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error)
+	// GetPriceByID returns ErrNotFound if no price has id.
+	GetPriceByID(ctx context.Context, id int) (Price, error)
+	// UpdatePrice replaces every field of the price with the same ID and
+	// returns ErrNotFound if it doesn't exist.
+	UpdatePrice(ctx context.Context, price Price) (Price, error)
+	// PatchPrice replaces the price with id by the result of patch applied
+	// to it, atomically so concurrent writes aren't lost, and returns
+	// ErrNotFound if it doesn't exist or any error of patch.
+	PatchPrice(ctx context.Context, id int, patch func(Price) (Price, error)) (Price, error)
+	// DeletePrice returns ErrNotFound if no price has id.
+	DeletePrice(ctx context.Context, id int) error
+	// AddBrand stores the brand with a generated ID and returns ErrConflict
+	// if the name is already taken.
+	AddBrand(ctx context.Context, name string) (Brand, error)
 	GetBrand(ctx context.Context, name string) (Brand, error)
+	// GetBrandByID returns ErrNotFound if no brand has id.
+	GetBrandByID(ctx context.Context, id int) (Brand, error)
+	// RenameBrand returns ErrNotFound if no brand has id and ErrConflict if
+	// the name is already taken.
+	RenameBrand(ctx context.Context, id int, name string) (Brand, error)
+	// DeleteBrand returns ErrNotFound if no brand has id and ErrConflict if
+	// any prices or promotions reference the brand.
+	DeleteBrand(ctx context.Context, id int) error
+	// AddPromotion stores the promotion with a generated ID, ignoring any
+	// provided ID, and returns a *ValidationError if the brand doesn't exist.
+	AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error)
+	// GetPromotionByID returns ErrNotFound if no promotion has id.
+	GetPromotionByID(ctx context.Context, id int) (Promotion, error)
+	// GetPromotions returns the brand's promotions including any of
+	// productIDs that apply at any time between from and to, inclusive,
+	// ordered by ID.
+	GetPromotions(ctx context.Context, brandID int, productIDs []int, from, to time.Time) ([]Promotion, error)
+	// DeletePromotion returns ErrNotFound if no promotion has id.
+	DeletePromotion(ctx context.Context, id int) error
+	// Ping returns an error if the backend can't currently serve requests,
+	// e.g: the database is unreachable.
+	Ping(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 }
 
+// RepositoryOption configures a repository created by NewInMemoryRepository
+// or NewPostgresRepository.
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	logger         *slog.Logger
+	tracerProvider trace.TracerProvider
+}
+
+// WithRepositoryLogger sets the logger of the repository, defaults to
+// slog.Default. Postgres logs SQL queries at debug level.
+func WithRepositoryLogger(logger *slog.Logger) RepositoryOption {
+	return func(o *repositoryOptions) { o.logger = logger }
+}
+
+// WithRepositoryTracerProvider creates spans for SQL queries and copies with
+// tp when using Postgres, defaults to no spans.
+func WithRepositoryTracerProvider(tp trace.TracerProvider) RepositoryOption {
+	return func(o *repositoryOptions) { o.tracerProvider = tp }
+}
+
+func newRepositoryOptions(opts []RepositoryOption) repositoryOptions {
+	o := repositoryOptions{logger: slog.Default(), tracerProvider: noop.NewTracerProvider()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
 type MockRepository struct {
 	// Could hold a few prices and brands so can test presence/absence
 }
@@ -23,26 +105,96 @@ func NewMockRepository() *MockRepository {
 	return &MockRepository{}
 }
 
-func (mr *MockRepository) AddPrice(ctx context.Context, price Price) error {
-	// TODO not implemented
-	return nil
+func (mr *MockRepository) AddPrice(ctx context.Context, price Price) (Price, error) {
+	price.ID = 1
+
+	return price, nil
+}
+
+func (mr *MockRepository) AddPrices(ctx context.Context, prices []Price) ([]Price, error) {
+	added := make([]Price, len(prices))
+	for i, price := range prices {
+		price.ID = i + 1
+		added[i] = price
+	}
+
+	return added, nil
 }
 
 func (mr *MockRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
 	return FinalPrice{
+		ID:        1,
 		BrandID:   brandID,
 		ProductID: productID,
 		StartDate: date,
 		EndDate:   date.Add(24 * time.Hour),
-		Price:     100,
-		Curr:      "USD",
+		// no overriding prices so effective for the full date range
+		EffectiveStartDate: date,
+		EffectiveEndDate:   date.Add(24 * time.Hour),
+		Price:              Money{Amount: 100, Currency: "USD"},
+	}, nil
+}
+
+func (mr *MockRepository) GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) (map[int]FinalPrice, error) {
+	prices := make(map[int]FinalPrice, len(productIDs))
+	for _, productID := range productIDs {
+		prices[productID], _ = mr.GetPrice(ctx, brandID, productID, date)
+	}
+
+	return prices, nil
+}
+
+func (mr *MockRepository) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error) {
+	return []PriceSegment{{
+		StartDate: from,
+		EndDate:   to,
+		PriceID:   1,
+		Price:     Money{Amount: 100, Currency: "USD"},
+	}}, nil
+}
+
+func (mr *MockRepository) GetPriceByID(ctx context.Context, id int) (Price, error) {
+	return Price{
+		ID:        id,
+		BrandID:   1,
+		StartDate: time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+		ProductID: 1234,
+		Priority:  0,
+		Price:     Money{Amount: 100, Currency: "USD"},
 	}, nil
 }
 
-func (mr *MockRepository) AddBrand(ctx context.Context, name string) error {
+func (mr *MockRepository) ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error) {
+	price, err := mr.GetPriceByID(ctx, 1)
+
+	return []Price{price}, err
+}
+
+func (mr *MockRepository) UpdatePrice(ctx context.Context, price Price) (Price, error) {
+	return price, nil
+}
+
+func (mr *MockRepository) PatchPrice(ctx context.Context, id int, patch func(Price) (Price, error)) (Price, error) {
+	price, err := mr.GetPriceByID(ctx, id)
+	if err != nil {
+		return Price{}, err
+	}
+
+	return patch(price)
+}
+
+func (mr *MockRepository) DeletePrice(ctx context.Context, id int) error {
 	return nil
 }
 
+func (mr *MockRepository) AddBrand(ctx context.Context, name string) (Brand, error) {
+	return Brand{
+		ID:   1234,
+		Name: name,
+	}, nil
+}
+
 func (mr *MockRepository) GetBrand(ctx context.Context, name string) (Brand, error) {
 	return Brand{
 		ID:   1234,
@@ -50,60 +202,54 @@ func (mr *MockRepository) GetBrand(ctx context.Context, name string) (Brand, err
 	}, nil
 }
 
-func (mr *MockRepository) Shutdown(ctx context.Context) error {
+func (mr *MockRepository) GetBrandByID(ctx context.Context, id int) (Brand, error) {
+	return Brand{
+		ID:   id,
+		Name: "EXAMPLE",
+	}, nil
+}
+
+func (mr *MockRepository) RenameBrand(ctx context.Context, id int, name string) (Brand, error) {
+	return Brand{
+		ID:   id,
+		Name: name,
+	}, nil
+}
+
+func (mr *MockRepository) DeleteBrand(ctx context.Context, id int) error {
 	return nil
 }
 
-func SeedExampleData(ctx context.Context, repo Repository) error {
-	t1, err := time.Parse("2006-01-02-15.04.05", "2020-06-14-00.00.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t2, err := time.Parse("2006-01-02-15.04.05", "2020-12-31-23.59.59")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t3, err := time.Parse("2006-01-02-15.04.05", "2020-06-14-15.00.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t4, err := time.Parse("2006-01-02-15.04.05", "2020-06-14-18.30.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t5, err := time.Parse("2006-01-02-15.04.05", "2020-06-15-00.00.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t6, err := time.Parse("2006-01-02-15.04.05", "2020-06-15-11.00.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t7, err := time.Parse("2006-01-02-15.04.05", "2020-06-15-16.00.00")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
-	t8, err := time.Parse("2006-01-02-15.04.05", "2020-12-31-23.59.59")
-	if err != nil {
-		return fmt.Errorf("failed to parse time: %w", err)
-	}
+func (mr *MockRepository) AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error) {
+	promotion.ID = 1
 
-	prices := []Price{
-		{BrandID: 1, StartDate: t1.UTC(), EndDate: t2.UTC(), ProductID: 35455, Priority: 0, Price: 3550, Curr: "EUR"},
-		{BrandID: 1, StartDate: t3.UTC(), EndDate: t4.UTC(), ProductID: 35455, Priority: 1, Price: 2545, Curr: "EUR"},
-		{BrandID: 1, StartDate: t5.UTC(), EndDate: t6.UTC(), ProductID: 35455, Priority: 1, Price: 3050, Curr: "EUR"},
-		{BrandID: 1, StartDate: t7.UTC(), EndDate: t8.UTC(), ProductID: 35455, Priority: 1, Price: 3895, Curr: "EUR"},
-	}
+	return promotion, nil
+}
 
-	if err := repo.AddBrand(ctx, "EXAMPLE"); err != nil {
-		return fmt.Errorf("failed to add brand: %w", err)
-	}
+func (mr *MockRepository) GetPromotionByID(ctx context.Context, id int) (Promotion, error) {
+	return Promotion{
+		ID:         id,
+		BrandID:    1,
+		ProductIDs: []int{1234},
+		StartDate:  time.Date(2020, 06, 14, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+		PercentOff: 10,
+	}, nil
+}
 
-	for _, price := range prices {
-		if err := repo.AddPrice(ctx, price); err != nil {
-			return fmt.Errorf("failed to add an initial price to repository: %w", err)
-		}
-	}
+// GetPromotions returns no promotions so GetPrice returns base prices.
+func (mr *MockRepository) GetPromotions(ctx context.Context, brandID int, productIDs []int, from, to time.Time) ([]Promotion, error) {
+	return nil, nil
+}
+
+func (mr *MockRepository) DeletePromotion(ctx context.Context, id int) error {
+	return nil
+}
 
+func (mr *MockRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (mr *MockRepository) Shutdown(ctx context.Context) error {
 	return nil
 }
@@ -0,0 +1,191 @@
+package pricing
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed seeds/example.json
+var embedSeeds embed.FS
+
+// Seed is the brands and prices to load into a Repository at startup.
+type Seed struct {
+	Brands []string    `json:"brands"` // Brand names, created if they don't exist.
+	Prices []SeedPrice `json:"prices"`
+}
+
+// SeedPrice is a price referring to its brand by name or ID, names are
+// preferred as generated brand IDs depend on the Repository's history.
+type SeedPrice struct {
+	Brand string `json:"brand,omitempty"` // Brand name, overrides brand_id.
+	AddPriceRequest
+}
+
+// LoadSeedFile reads a seed file by its extension, either a .json Seed or a
+// .csv price list, see ReadPricesCSV. CSV files may name the brand of each
+// price in an extra BRAND column, creating it if it doesn't exist, otherwise
+// the BRAND_ID must already exist.
+func LoadSeedFile(path string) (Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Seed{}, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return decodeSeedJSON(data)
+	case ".csv":
+		rows, err := readCSVRows(bytes.NewReader(data), true)
+		if err != nil {
+			return Seed{}, fmt.Errorf("failed to read seed file: %w", err)
+		}
+
+		var seed Seed
+		for _, row := range rows {
+			p := row.price
+			seed.Prices = append(seed.Prices, SeedPrice{Brand: row.brand, AddPriceRequest: AddPriceRequest{
+				BrandID:   p.BrandID,
+				StartDate: p.StartDate,
+				EndDate:   p.EndDate,
+				ProductID: p.ProductID,
+				Priority:  p.Priority,
+				Price:     p.Price,
+			}})
+		}
+
+		return seed, nil
+	default:
+		return Seed{}, fmt.Errorf("unsupported seed file extension: %q, must be .json or .csv", ext)
+	}
+}
+
+func decodeSeedJSON(data []byte) (Seed, error) {
+	var seed Seed
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&seed); err != nil {
+		return Seed{}, fmt.Errorf("failed to decode seed: %w", err)
+	}
+
+	return seed, nil
+}
+
+// SeedExampleData loads the EXAMPLE brand and its prices shipped in
+// ./seeds/example.json.
+func SeedExampleData(ctx context.Context, repo Repository) error {
+	data, err := embedSeeds.ReadFile("seeds/example.json")
+	if err != nil {
+		return fmt.Errorf("failed to read example seed: %w", err)
+	}
+
+	seed, err := decodeSeedJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return SeedRepository(ctx, repo, seed)
+}
+
+// SeedRepository loads seed into repo idempotently so it's safe to run on
+// every startup. Brands are matched by name and prices by brand, product,
+// start and end dates and priority. Existing prices with a different amount
+// are updated, new prices are added together in a single batch.
+func SeedRepository(ctx context.Context, repo Repository, seed Seed) error {
+	brandIDs := map[string]int{}
+	brandID := func(name string) (int, error) {
+		if id, ok := brandIDs[name]; ok {
+			return id, nil
+		}
+
+		brand, err := repo.GetBrand(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			brand, err = repo.AddBrand(ctx, name)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to seed brand: %s: %w", name, err)
+		}
+		brandIDs[name] = brand.ID
+
+		return brand.ID, nil
+	}
+
+	for _, name := range seed.Brands {
+		if _, err := brandID(name); err != nil {
+			return err
+		}
+	}
+
+	existing := map[productKey][]Price{}
+	var added []Price
+	for i, sp := range seed.Prices {
+		price := Price{
+			BrandID:   sp.BrandID,
+			StartDate: sp.StartDate.UTC(),
+			EndDate:   sp.EndDate.UTC(),
+			ProductID: sp.ProductID,
+			Priority:  sp.Priority,
+			Price:     sp.Price,
+		}
+
+		if sp.Brand != "" {
+			id, err := brandID(sp.Brand)
+			if err != nil {
+				return err
+			}
+			price.BrandID = id
+		}
+
+		if err := price.Validate(); err != nil {
+			return fmt.Errorf("invalid seed price: %d: %w", i, err)
+		}
+
+		key := productKey{brandID: price.BrandID, productID: price.ProductID}
+		if _, ok := existing[key]; !ok {
+			prices, err := repo.ListPrices(ctx, PriceFilter{BrandID: key.brandID, ProductID: key.productID})
+			if err != nil {
+				return fmt.Errorf("failed to list existing prices: %w", err)
+			}
+			existing[key] = prices
+		}
+
+		stored, ok := findSeededPrice(existing[key], price)
+		switch {
+		case !ok:
+			added = append(added, price)
+		case stored.Price != price.Price:
+			price.ID = stored.ID
+			if _, err := repo.UpdatePrice(ctx, price); err != nil {
+				return fmt.Errorf("failed to update seed price: %d: %w", i, err)
+			}
+		}
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	if _, err := repo.AddPrices(ctx, added); err != nil {
+		return fmt.Errorf("failed to add seed prices: %w", err)
+	}
+
+	return nil
+}
+
+// findSeededPrice returns the stored price a seed price corresponds to.
+func findSeededPrice(stored []Price, price Price) (Price, bool) {
+	for _, p := range stored {
+		if p.StartDate.Equal(price.StartDate) && p.EndDate.Equal(price.EndDate) && p.Priority == price.Priority {
+			return p, true
+		}
+	}
+
+	return Price{}, false
+}
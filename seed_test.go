@@ -0,0 +1,206 @@
+package pricing_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karlskewes/pricing"
+)
+
+func TestSeedExampleData_Idempotent(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := pricing.SeedExampleData(ctx, db); err != nil {
+			t.Fatalf("seed: %d: %v", i, err)
+		}
+	}
+
+	want, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		want[i].ID = i + 1
+	}
+
+	got, err := db.ListPrices(ctx, pricing.PriceFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ListPrices(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSeedRepository_Upsert(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pricing.SeedExampleData(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := prices[1]
+	changed.Price = pricing.Money{Amount: 1999, Currency: "EUR"}
+	added := prices[0]
+	added.ProductID = 35456
+
+	seed := pricing.Seed{Prices: []pricing.SeedPrice{
+		{Brand: "EXAMPLE", AddPriceRequest: pricing.AddPriceRequest{StartDate: changed.StartDate, EndDate: changed.EndDate, ProductID: changed.ProductID, Priority: changed.Priority, Price: changed.Price}},
+		{AddPriceRequest: pricing.AddPriceRequest{BrandID: 1, StartDate: added.StartDate, EndDate: added.EndDate, ProductID: added.ProductID, Priority: added.Priority, Price: added.Price}},
+	}}
+
+	if err := pricing.SeedRepository(ctx, db, seed); err != nil {
+		t.Fatal(err)
+	}
+
+	changed.ID = 2
+	added.ID = 5
+	want := []pricing.Price{changed, added}
+
+	var got []pricing.Price
+	for _, id := range []int{2, 5} {
+		price, err := db.GetPriceByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, price)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("seeded prices mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadSeedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"seed.json": `{"brands":["EXAMPLE"],"prices":[{"brand":"EXAMPLE","start_date":"2020-06-14T00:00:00Z","end_date":"2020-12-31T23:59:59Z","product_id":35455,"priority":0,"price":{"amount":"35.50","currency":"EUR"}}]}`,
+		"seed.csv":  "BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n",
+		"brand.csv": "BRAND,START_DATE,END_DATE,PRODUCT_ID,PRIORITY,PRICE,CURR\nEXAMPLE,2020-06-14-00.00.00,2020-12-31-23.59.59,35455,0,35.50,EUR\n",
+		"seed.yaml": "brands: [EXAMPLE]\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prices, err := initialPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	price := pricing.AddPriceRequest{StartDate: prices[0].StartDate, EndDate: prices[0].EndDate, ProductID: 35455, Price: prices[0].Price}
+	priceByID := price
+	priceByID.BrandID = 1
+
+	testCases := map[string]struct {
+		want    pricing.Seed
+		wantErr bool
+	}{
+		"seed.json": {want: pricing.Seed{Brands: []string{"EXAMPLE"}, Prices: []pricing.SeedPrice{{Brand: "EXAMPLE", AddPriceRequest: price}}}},
+		"seed.csv":  {want: pricing.Seed{Prices: []pricing.SeedPrice{{AddPriceRequest: priceByID}}}},
+		"brand.csv": {want: pricing.Seed{Prices: []pricing.SeedPrice{{Brand: "EXAMPLE", AddPriceRequest: price}}}},
+		"seed.yaml": {wantErr: true},
+		"none.json": {wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := pricing.LoadSeedFile(filepath.Join(dir, name))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSeedFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LoadSeedFile(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSeedRepository_CSV(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	testCases := map[string]struct {
+		csv     string
+		wantErr bool
+	}{
+		"brand names": {
+			csv: "BRAND,START_DATE,END_DATE,PRODUCT_ID,PRIORITY,PRICE,CURR\n" +
+				"EXAMPLE,2020-06-14-00.00.00,2020-12-31-23.59.59,35455,0,35.50,EUR\n",
+		},
+		"brand names and ids": {
+			csv: "BRAND_ID,BRAND,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n" +
+				",EXAMPLE,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n",
+		},
+		"brand id does not exist": {
+			csv:     "BRAND_ID,START_DATE,END_DATE,PRICE_LIST,PRODUCT_ID,PRIORITY,PRICE,CURR\n1,2020-06-14-00.00.00,2020-12-31-23.59.59,,35455,0,35.50,EUR\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			seed, err := pricing.LoadSeedFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			db, err := pricing.NewInMemoryRepository(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = pricing.SeedRepository(ctx, db, seed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SeedRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			brand, err := db.GetBrand(ctx, "EXAMPLE")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := db.GetPrice(ctx, brand.ID, 35455, time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := (pricing.Money{Amount: 3550, Currency: "EUR"}); got.Price != want {
+				t.Errorf("want seeded price: %s - got: %s", want, got.Price)
+			}
+		})
+	}
+}
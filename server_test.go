@@ -3,26 +3,38 @@ package pricing_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/karlskewes/pricing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
-func makeGetPriceHTTPRequest(req pricing.GetPriceRequest) (pricing.GetPriceResponse, error) {
+// testClient doesn't keep connections alive, idle connections the server
+// hasn't seen a request on yet delay its shutdown.
+var testClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+func makeGetPriceHTTPRequest(baseURL string, req pricing.GetPriceRequest) (pricing.GetPriceResponse, error) {
 	var got pricing.GetPriceResponse
 
-	// TODO: let tests find and define port to listen on
-	url := fmt.Sprintf("http://localhost:8080/api/v1/prices?brand_id=%d&product_id=%d&date=%s&string_id=%s", req.BrandID, req.ProductID, req.Date.Format(time.RFC3339), req.StringID)
+	url := fmt.Sprintf("%s/api/v1/prices?brand_id=%d&product_id=%d&date=%s&string_id=%s", baseURL, req.BrandID, req.ProductID, req.Date.Format(time.RFC3339), req.StringID)
+	if req.LowestPriorPrice {
+		url += "&lowest_prior_price=true"
+	}
 
-	resp, err := http.Get(url)
+	resp, err := testClient.Get(url)
 	if err != nil {
 		return got, fmt.Errorf("unexpected error: %v", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return got, fmt.Errorf("unexpected http status: %d", resp.StatusCode)
@@ -36,48 +48,52 @@ func makeGetPriceHTTPRequest(req pricing.GetPriceRequest) (pricing.GetPriceRespo
 	return got, nil
 }
 
-// TestRun is an almost complete end to end test. Whilst it doesn't call main()
-// it does execute the application with defaults, including HTTP listen address
-// and repository configuration. Tests will fail if port 8080 isn't available
-// to bind to.
-// An alternative is to listen on a random port decided by httptest.NewServer
-// per the tests in ./api_test.go
-func TestRun(t *testing.T) {
-	// t.Parallel() // insignficant speed boost and makes it easier to review
-	app, err := pricing.NewApp([]string{"pricing"})
-	if err != nil {
-		log.Fatalf("failed to create new App: %v", err)
-	}
-
-	// nolint:errcheck // http request will fail if server doesn't startup
-	go app.Run(context.Background())
+// startApp runs an App with opts listening on a random port until the test
+// completes and returns its base URL.
+func startApp(t *testing.T, opts ...pricing.Option) string {
+	t.Helper()
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8080", nil)
+	defaults := []pricing.Option{
+		pricing.WithListenAddr("localhost:0"),
+		pricing.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+	opts = append(defaults, opts...)
+	app, err := pricing.NewApp(opts...)
 	if err != nil {
-		t.Fatalf("failed to create a new request: %v", err)
+		t.Fatalf("failed to create new App: %v", err)
 	}
 
-	// HTTP server `go main()` goroutine might not be scheduled yet.
-	// Attempt GET request a few times with a delay between each request.
-	client := &http.Client{}
-	var resp *http.Response
-	var doErr error
+	if err := app.Listen(); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
 
-	for i := 0; i < 3; i++ {
-		resp, doErr = client.Do(req)
-		if doErr == nil {
-			defer resp.Body.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
 
-			break
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("app.Run() error: %v", err)
 		}
+	})
 
-		// wait for server to startup
-		time.Sleep(time.Duration(i) * time.Second)
-	}
+	return "http://" + app.Addr().String()
+}
+
+// TestRun is an almost complete end to end test. Whilst it doesn't call main()
+// it does execute the application with defaults, including repository
+// configuration, listening on a random port.
+func TestRun(t *testing.T) {
+	t.Parallel()
 
-	if doErr != nil {
-		t.Fatalf("failed to query HTTP server")
+	baseURL := startApp(t, pricing.WithConfig(testConfig()))
+
+	resp, err := testClient.Get(baseURL)
+	if err != nil {
+		t.Fatalf("failed to query HTTP server: %v", err)
 	}
+	defer resp.Body.Close()
 
 	want := http.StatusNotFound
 	if resp.StatusCode != want {
@@ -98,28 +114,28 @@ func TestRun(t *testing.T) {
 		wantErr bool
 	}{
 		"Test 1": {
-			input:   pricing.GetPriceRequest{1, 35455, time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC), "test_1"},
-			want:    pricing.GetPriceResponse{1, 35455, "35.50", "EUR", "2020-06-14 00:00:00 +0000 UTC", "2020-12-31 23:59:59 +0000 UTC", "test_1"},
+			input:   pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC), StringID: "test_1", LowestPriorPrice: true},
+			want:    pricing.GetPriceResponse{PriceID: 1, BrandID: 1, ProductID: 35455, Price: pricing.Money{Amount: 3550, Currency: "EUR"}, OriginalPrice: pricing.Money{Amount: 3550, Currency: "EUR"}, Discount: pricing.Money{Currency: "EUR"}, StartDate: time.Date(2020, 06, 14, 00, 00, 00, 0, time.UTC), EndDate: time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), EffectiveStartDate: time.Date(2020, 06, 14, 00, 00, 00, 0, time.UTC), EffectiveEndDate: time.Date(2020, 06, 14, 14, 59, 59, 999999999, time.UTC), StringID: "test_1"},
 			wantErr: false,
 		},
 		"Test 2": {
-			input:   pricing.GetPriceRequest{1, 35455, time.Date(2020, 06, 14, 16, 0, 0, 0, time.UTC), "test_2"},
-			want:    pricing.GetPriceResponse{1, 35455, "25.45", "EUR", "2020-06-14 15:00:00 +0000 UTC", "2020-06-14 18:30:00 +0000 UTC", "test_2"},
+			input:   pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: time.Date(2020, 06, 14, 16, 0, 0, 0, time.UTC), StringID: "test_2", LowestPriorPrice: true},
+			want:    pricing.GetPriceResponse{PriceID: 2, BrandID: 1, ProductID: 35455, Price: pricing.Money{Amount: 2545, Currency: "EUR"}, CompareAtPriceID: 1, CompareAtPrice: &pricing.Money{Amount: 3550, Currency: "EUR"}, OriginalPrice: pricing.Money{Amount: 2545, Currency: "EUR"}, Discount: pricing.Money{Currency: "EUR"}, LowestPriorPrice: &pricing.Money{Amount: 3550, Currency: "EUR"}, StartDate: time.Date(2020, 06, 14, 15, 00, 00, 0, time.UTC), EndDate: time.Date(2020, 06, 14, 18, 30, 00, 0, time.UTC), EffectiveStartDate: time.Date(2020, 06, 14, 15, 00, 00, 0, time.UTC), EffectiveEndDate: time.Date(2020, 06, 14, 18, 30, 00, 0, time.UTC), StringID: "test_2"},
 			wantErr: false,
 		},
 		"Test 3": {
-			input:   pricing.GetPriceRequest{1, 35455, time.Date(2020, 06, 14, 21, 0, 0, 0, time.UTC), "test_3"},
-			want:    pricing.GetPriceResponse{1, 35455, "35.50", "EUR", "2020-06-14 00:00:00 +0000 UTC", "2020-12-31 23:59:59 +0000 UTC", "test_3"},
+			input:   pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: time.Date(2020, 06, 14, 21, 0, 0, 0, time.UTC), StringID: "test_3", LowestPriorPrice: true},
+			want:    pricing.GetPriceResponse{PriceID: 1, BrandID: 1, ProductID: 35455, Price: pricing.Money{Amount: 3550, Currency: "EUR"}, OriginalPrice: pricing.Money{Amount: 3550, Currency: "EUR"}, Discount: pricing.Money{Currency: "EUR"}, LowestPriorPrice: &pricing.Money{Amount: 2545, Currency: "EUR"}, StartDate: time.Date(2020, 06, 14, 00, 00, 00, 0, time.UTC), EndDate: time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), EffectiveStartDate: time.Date(2020, 06, 14, 18, 30, 00, 1, time.UTC), EffectiveEndDate: time.Date(2020, 06, 14, 23, 59, 59, 999999999, time.UTC), StringID: "test_3"},
 			wantErr: false,
 		},
 		"Test 4": {
-			input:   pricing.GetPriceRequest{1, 35455, time.Date(2020, 06, 15, 10, 0, 0, 0, time.UTC), "test_4"},
-			want:    pricing.GetPriceResponse{1, 35455, "30.50", "EUR", "2020-06-15 00:00:00 +0000 UTC", "2020-06-15 11:00:00 +0000 UTC", "test_4"},
+			input:   pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: time.Date(2020, 06, 15, 10, 0, 0, 0, time.UTC), StringID: "test_4", LowestPriorPrice: true},
+			want:    pricing.GetPriceResponse{PriceID: 3, BrandID: 1, ProductID: 35455, Price: pricing.Money{Amount: 3050, Currency: "EUR"}, CompareAtPriceID: 1, CompareAtPrice: &pricing.Money{Amount: 3550, Currency: "EUR"}, OriginalPrice: pricing.Money{Amount: 3050, Currency: "EUR"}, Discount: pricing.Money{Currency: "EUR"}, LowestPriorPrice: &pricing.Money{Amount: 2545, Currency: "EUR"}, StartDate: time.Date(2020, 06, 15, 00, 00, 00, 0, time.UTC), EndDate: time.Date(2020, 06, 15, 11, 00, 00, 0, time.UTC), EffectiveStartDate: time.Date(2020, 06, 15, 00, 00, 00, 0, time.UTC), EffectiveEndDate: time.Date(2020, 06, 15, 11, 00, 00, 0, time.UTC), StringID: "test_4"},
 			wantErr: false,
 		},
 		"Test 5": {
-			input:   pricing.GetPriceRequest{1, 35455, time.Date(2020, 06, 16, 21, 0, 0, 0, time.UTC), "test_5"},
-			want:    pricing.GetPriceResponse{1, 35455, "38.95", "EUR", "2020-06-15 16:00:00 +0000 UTC", "2020-12-31 23:59:59 +0000 UTC", "test_5"},
+			input:   pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: time.Date(2020, 06, 16, 21, 0, 0, 0, time.UTC), StringID: "test_5", LowestPriorPrice: true},
+			want:    pricing.GetPriceResponse{PriceID: 4, BrandID: 1, ProductID: 35455, Price: pricing.Money{Amount: 3895, Currency: "EUR"}, CompareAtPriceID: 1, CompareAtPrice: &pricing.Money{Amount: 3550, Currency: "EUR"}, OriginalPrice: pricing.Money{Amount: 3895, Currency: "EUR"}, Discount: pricing.Money{Currency: "EUR"}, LowestPriorPrice: &pricing.Money{Amount: 2545, Currency: "EUR"}, StartDate: time.Date(2020, 06, 15, 16, 00, 00, 0, time.UTC), EndDate: time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), EffectiveStartDate: time.Date(2020, 06, 15, 16, 00, 00, 0, time.UTC), EffectiveEndDate: time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), StringID: "test_5"},
 			wantErr: false,
 		},
 	}
@@ -131,7 +147,7 @@ func TestRun(t *testing.T) {
 			// makes it harder to compare.
 			// No race conditions seem to occur when enabled.
 
-			got, err := makeGetPriceHTTPRequest(tt.input)
+			got, err := makeGetPriceHTTPRequest(baseURL, tt.input)
 			if err != nil {
 				t.Errorf("unexpected error calling API endpoint: %v", err)
 			}
@@ -143,3 +159,337 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+// testConfig is the DefaultConfig without a shutdown delay so tests complete
+// quickly.
+func testConfig() pricing.Config {
+	cfg := pricing.DefaultConfig()
+	cfg.HTTP.ShutdownPreStopDelay = 0
+
+	return cfg
+}
+
+func TestNewApp_Options(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	repo, err := pricing.NewInMemoryRepository(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brand, err := repo.AddBrand(ctx, "CUSTOM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+	price, err := repo.AddPrice(ctx, pricing.Price{BrandID: brand.ID, StartDate: date, EndDate: date.Add(time.Hour), ProductID: 1, Price: pricing.Money{Amount: 100, Currency: "USD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.Seed.Enabled = false
+
+	header := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Test", "middleware")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	baseURL := startApp(t,
+		pricing.WithConfig(cfg),
+		pricing.WithRepository(repo),
+		pricing.WithMiddleware(header),
+		pricing.WithClock(func() time.Time { return date }),
+	)
+
+	got, err := makeGetPriceHTTPRequest(baseURL, pricing.GetPriceRequest{BrandID: brand.ID, ProductID: 1, Date: date, StringID: "custom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PriceID != price.ID || got.Price != price.Price {
+		t.Errorf("want price: %d %v - got: %d %v", price.ID, price.Price, got.PriceID, got.Price)
+	}
+
+	resp, err := testClient.Get(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("X-Test"); got != "middleware" {
+		t.Errorf("want X-Test header: middleware - got: %q", got)
+	}
+}
+
+// shutdownRepository records Shutdown calls and returns err.
+type shutdownRepository struct {
+	pricing.Repository
+	err    error
+	called chan struct{}
+}
+
+func (sr *shutdownRepository) Shutdown(ctx context.Context) error {
+	close(sr.called)
+
+	return sr.err
+}
+
+func TestApp_RunShutsDownRepository(t *testing.T) {
+	t.Parallel()
+
+	errShutdown := errors.New("shutdown failed")
+
+	testCases := map[string]struct {
+		err     error
+		wantErr error
+	}{
+		"ok":    {},
+		"error": {err: errShutdown, wantErr: errShutdown},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &shutdownRepository{Repository: pricing.NewMockRepository(), err: tt.err, called: make(chan struct{})}
+
+			cfg := testConfig()
+			cfg.Seed.Enabled = false
+
+			app, err := pricing.NewApp(pricing.WithConfig(cfg), pricing.WithListenAddr("localhost:0"), pricing.WithRepository(repo))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err = app.Run(ctx)
+
+			select {
+			case <-repo.called:
+			default:
+				t.Fatal("repository wasn't shut down")
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("want error: %v - got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// pingRepository fails readiness checks with err.
+type pingRepository struct {
+	pricing.Repository
+	err error
+}
+
+func (pr pingRepository) Ping(ctx context.Context) error {
+	return pr.err
+}
+
+func TestApp_Health(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig()
+	cfg.Seed.Enabled = false
+
+	testCases := map[string]struct {
+		path       string
+		pingErr    error
+		wantStatus int
+	}{
+		"healthz":           {path: "/healthz", wantStatus: http.StatusOK},
+		"healthz not ready": {path: "/healthz", pingErr: pricing.ErrUnavailable, wantStatus: http.StatusOK},
+		"readyz":            {path: "/readyz", wantStatus: http.StatusOK},
+		"readyz not ready":  {path: "/readyz", pingErr: pricing.ErrUnavailable, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := pingRepository{Repository: pricing.NewMockRepository(), err: tt.pingErr}
+			baseURL := startApp(t, pricing.WithConfig(cfg), pricing.WithRepository(repo))
+
+			resp, err := testClient.Get(baseURL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("want: %d - got: %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestApp_ReadyzDraining(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig()
+	cfg.Seed.Enabled = false
+	cfg.HTTP.ShutdownPreStopDelay = 2 * time.Second
+
+	app, err := pricing.NewApp(pricing.WithConfig(cfg), pricing.WithListenAddr("localhost:0"), pricing.WithRepository(pricing.NewMockRepository()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Listen(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+
+	readyz := func() int {
+		resp, err := testClient.Get("http://" + app.Addr().String() + "/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if got := readyz(); got != http.StatusOK {
+		t.Fatalf("before shutdown want: %d - got: %d", http.StatusOK, got)
+	}
+
+	cancel()
+
+	// requests are still served during the pre-stop delay, readiness fails
+	// once draining starts.
+	got := readyz()
+	for i := 0; i < 10 && got == http.StatusOK; i++ {
+		time.Sleep(50 * time.Millisecond)
+		got = readyz()
+	}
+	if got != http.StatusServiceUnavailable {
+		t.Errorf("draining want: %d - got: %d", http.StatusServiceUnavailable, got)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("app.Run() error: %v", err)
+	}
+}
+
+func TestApp_Metrics(t *testing.T) {
+	t.Parallel()
+
+	baseURL := startApp(t, pricing.WithConfig(testConfig()))
+
+	date := time.Date(2020, 06, 14, 10, 0, 0, 0, time.UTC)
+	if _, err := makeGetPriceHTTPRequest(baseURL, pricing.GetPriceRequest{BrandID: 1, ProductID: 35455, Date: date, StringID: "hit"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := makeGetPriceHTTPRequest(baseURL, pricing.GetPriceRequest{BrandID: 1, ProductID: 1, Date: date, StringID: "miss"}); err == nil {
+		t.Fatal("want error for product without a price")
+	}
+	if _, err := makeGetPriceHTTPRequest(baseURL, pricing.GetPriceRequest{BrandID: 999, ProductID: 1, Date: date, StringID: "miss"}); err == nil {
+		t.Fatal("want error for brand without prices")
+	}
+
+	// batches count every product
+	batch, err := testClient.Post(baseURL+"/api/v1/prices:batchGet", "application/json",
+		strings.NewReader(`{"brand_id":1,"product_ids":[35455,1,2],"date":"2020-06-14T10:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Body.Close()
+	if batch.StatusCode != http.StatusOK {
+		t.Fatalf("batch want: %d - got: %d", http.StatusOK, batch.StatusCode)
+	}
+
+	resp, err := testClient.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`pricing_http_requests_total{method="GET",route="/api/v1/prices",status="200"} 1`,
+		`pricing_http_requests_total{method="GET",route="/api/v1/prices",status="404"} 2`,
+		`pricing_http_request_duration_seconds_count{method="GET",route="/api/v1/prices",status="200"} 1`,
+		`pricing_price_lookups_total{brand_id="1",result="hit"} 2`,
+		`pricing_price_lookups_total{brand_id="1",result="miss"} 3`,
+		`pricing_price_lookups_total{brand_id="unknown",result="miss"} 1`,
+		`pricing_repository_operation_duration_seconds_count{backend="inmemory",operation="get_price"} 3`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing: %s", want)
+		}
+	}
+
+	// clients choose the brand_id of misses, labelling brands that don't
+	// exist is unbounded
+	for _, unwanted := range []string{`brand_id="999"`} {
+		if strings.Contains(string(body), unwanted) {
+			t.Errorf("metrics unexpectedly contain: %s", unwanted)
+		}
+	}
+}
+
+func TestApp_Tracing(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	baseURL := startApp(t, pricing.WithConfig(testConfig()), pricing.WithTracerProvider(tp))
+
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/api/v1/prices?brand_id=1&product_id=35455&date=2020-06-14T10:00:00Z&string_id=trace", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := testClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// the server span ends once the handler returns, possibly after the
+	// response is received.
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for i := 0; i < 20 && len(spans) < 3; i++ {
+		for _, span := range sr.Ended() {
+			spans[span.Name()] = span
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// each span is the child of the next, the HTTP span of the remote caller's.
+	chain := []string{"Repository.GetPrice", "Service.GetPrice", "GET /api/v1/prices"}
+	for i, name := range chain {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("missing span: %s - got: %v", name, spans)
+		}
+
+		if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("%s want trace ID from traceparent - got: %s", name, got)
+		}
+
+		wantParent := "00f067aa0ba902b7"
+		if i+1 < len(chain) {
+			wantParent = spans[chain[i+1]].SpanContext().SpanID().String()
+		}
+		if got := span.Parent().SpanID().String(); got != wantParent {
+			t.Errorf("%s want parent: %s - got: %s", name, wantParent, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pricing
+
+import (
+	"hash/fnv"
+	"maps"
+)
+
+// numShards splits each snapshot map so a write clones roughly 1/numShards of
+// it rather than all of it.
+const numShards = 256
+
+// shardedMap is a copy on write map for snapshots. Forking it copies the
+// shard pointers only, the first write to a shard after a fork clones that
+// shard alone so later writes in the same update don't clone it again.
+type shardedMap[K comparable, V any] struct {
+	shards [numShards]map[K]V
+	owned  [numShards]bool // shards cloned since the fork, safe to modify
+	length int
+	shard  func(K) uint
+}
+
+func newShardedMap[K comparable, V any](shard func(K) uint) *shardedMap[K, V] {
+	return &shardedMap[K, V]{shard: shard}
+}
+
+// fork returns a copy of m sharing every shard until written to.
+func (m *shardedMap[K, V]) fork() *shardedMap[K, V] {
+	next := &shardedMap[K, V]{
+		shards: m.shards,
+		length: m.length,
+		shard:  m.shard,
+	}
+
+	return next
+}
+
+func (m *shardedMap[K, V]) get(key K) (V, bool) {
+	v, ok := m.shards[m.shard(key)%numShards][key]
+
+	return v, ok
+}
+
+// set stores value with key, cloning the shard if m doesn't own it yet.
+func (m *shardedMap[K, V]) set(key K, value V) {
+	shard := m.writable(key)
+	if _, ok := shard[key]; !ok {
+		m.length++
+	}
+	shard[key] = value
+}
+
+// delete removes key, cloning the shard if m doesn't own it yet.
+func (m *shardedMap[K, V]) delete(key K) {
+	if _, ok := m.get(key); !ok {
+		return
+	}
+
+	delete(m.writable(key), key)
+	m.length--
+}
+
+func (m *shardedMap[K, V]) len() int {
+	return m.length
+}
+
+// all calls fn for every entry in no particular order until fn returns false.
+func (m *shardedMap[K, V]) all(fn func(K, V) bool) {
+	for _, shard := range m.shards {
+		for k, v := range shard {
+			if !fn(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// writable returns the shard of key, cloned if m doesn't own it yet.
+func (m *shardedMap[K, V]) writable(key K) map[K]V {
+	i := m.shard(key) % numShards
+	if !m.owned[i] {
+		m.shards[i] = maps.Clone(m.shards[i])
+		if m.shards[i] == nil {
+			m.shards[i] = map[K]V{}
+		}
+		m.owned[i] = true
+	}
+
+	return m.shards[i]
+}
+
+func shardInt(id int) uint {
+	return uint(id)
+}
+
+func shardProductKey(key productKey) uint {
+	return uint(key.brandID)*31 + uint(key.productID)
+}
+
+func shardString(s string) uint {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+
+	return uint(h.Sum32())
+}
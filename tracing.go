@@ -0,0 +1,363 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created by the package.
+const tracerName = "github.com/karlskewes/pricing"
+
+// propagator reads and writes W3C traceparent and baggage headers.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// NewTracerProvider returns a tracer provider exporting spans as configured,
+// stdout spans are written to w. It returns nil if the exporter is none. Shut
+// the provider down to flush any buffered spans.
+func (tc TracingConfig) NewTracerProvider(ctx context.Context, w io.Writer) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch tc.Exporter {
+	case "none":
+		return nil, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if tc.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(tc.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid tracing.exporter: %q: must be none, stdout or otlp", tc.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s span exporter: %w", tc.Exporter, err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("pricing"))),
+	), nil
+}
+
+// recordSpanError records err on span unless it's nil or ErrNotFound, which
+// is an expected outcome rather than a failure.
+func recordSpanError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// endSpan records any err on span, see recordSpanError, and ends it.
+func endSpan(span trace.Span, err error) {
+	recordSpanError(span, err)
+	span.End()
+}
+
+// tracedRepository creates a span for every call of the Repository, except
+// Ping and Shutdown.
+type tracedRepository struct {
+	Repository
+	tracer  trace.Tracer
+	backend string
+}
+
+// traceRepository returns repo creating spans with tracer labelled by
+// backend, e.g: postgres.
+func traceRepository(repo Repository, tracer trace.Tracer, backend string) Repository {
+	return &tracedRepository{Repository: repo, tracer: tracer, backend: backend}
+}
+
+// repositoryOperationKey is the context key of the Repository method being
+// traced, query spans are named after it.
+type repositoryOperationKey struct{}
+
+func (tr *tracedRepository) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("repository.backend", tr.backend))
+	ctx = context.WithValue(ctx, repositoryOperationKey{}, operation)
+
+	return tr.tracer.Start(ctx, "Repository."+operation, trace.WithAttributes(attrs...))
+}
+
+func (tr *tracedRepository) AddPrice(ctx context.Context, price Price) (Price, error) {
+	ctx, span := tr.start(ctx, "AddPrice", attribute.Int("brand_id", price.BrandID), attribute.Int("product_id", price.ProductID))
+	price, err := tr.Repository.AddPrice(ctx, price)
+	endSpan(span, err)
+
+	return price, err
+}
+
+func (tr *tracedRepository) AddPrices(ctx context.Context, prices []Price) ([]Price, error) {
+	ctx, span := tr.start(ctx, "AddPrices", attribute.Int("prices", len(prices)))
+	prices, err := tr.Repository.AddPrices(ctx, prices)
+	endSpan(span, err)
+
+	return prices, err
+}
+
+func (tr *tracedRepository) GetPrice(ctx context.Context, brandID, productID int, date time.Time) (FinalPrice, error) {
+	ctx, span := tr.start(ctx, "GetPrice", attribute.Int("brand_id", brandID), attribute.Int("product_id", productID))
+	price, err := tr.Repository.GetPrice(ctx, brandID, productID, date)
+	endSpan(span, err)
+
+	return price, err
+}
+
+func (tr *tracedRepository) GetPrices(ctx context.Context, brandID int, productIDs []int, date time.Time) (map[int]FinalPrice, error) {
+	ctx, span := tr.start(ctx, "GetPrices", attribute.Int("brand_id", brandID), attribute.Int("products", len(productIDs)))
+	prices, err := tr.Repository.GetPrices(ctx, brandID, productIDs, date)
+	endSpan(span, err)
+
+	return prices, err
+}
+
+func (tr *tracedRepository) GetPriceTimeline(ctx context.Context, brandID, productID int, from, to time.Time) ([]PriceSegment, error) {
+	ctx, span := tr.start(ctx, "GetPriceTimeline", attribute.Int("brand_id", brandID), attribute.Int("product_id", productID))
+	segments, err := tr.Repository.GetPriceTimeline(ctx, brandID, productID, from, to)
+	endSpan(span, err)
+
+	return segments, err
+}
+
+func (tr *tracedRepository) ListPrices(ctx context.Context, filter PriceFilter) ([]Price, error) {
+	ctx, span := tr.start(ctx, "ListPrices", attribute.Int("brand_id", filter.BrandID), attribute.Int("product_id", filter.ProductID))
+	prices, err := tr.Repository.ListPrices(ctx, filter)
+	endSpan(span, err)
+
+	return prices, err
+}
+
+func (tr *tracedRepository) GetPriceByID(ctx context.Context, id int) (Price, error) {
+	ctx, span := tr.start(ctx, "GetPriceByID", attribute.Int("price_id", id))
+	price, err := tr.Repository.GetPriceByID(ctx, id)
+	endSpan(span, err)
+
+	return price, err
+}
+
+func (tr *tracedRepository) UpdatePrice(ctx context.Context, price Price) (Price, error) {
+	ctx, span := tr.start(ctx, "UpdatePrice", attribute.Int("price_id", price.ID))
+	price, err := tr.Repository.UpdatePrice(ctx, price)
+	endSpan(span, err)
+
+	return price, err
+}
+
+func (tr *tracedRepository) PatchPrice(ctx context.Context, id int, patch func(Price) (Price, error)) (Price, error) {
+	ctx, span := tr.start(ctx, "PatchPrice", attribute.Int("price_id", id))
+	price, err := tr.Repository.PatchPrice(ctx, id, patch)
+	endSpan(span, err)
+
+	return price, err
+}
+
+func (tr *tracedRepository) DeletePrice(ctx context.Context, id int) error {
+	ctx, span := tr.start(ctx, "DeletePrice", attribute.Int("price_id", id))
+	err := tr.Repository.DeletePrice(ctx, id)
+	endSpan(span, err)
+
+	return err
+}
+
+func (tr *tracedRepository) AddBrand(ctx context.Context, name string) (Brand, error) {
+	ctx, span := tr.start(ctx, "AddBrand")
+	brand, err := tr.Repository.AddBrand(ctx, name)
+	endSpan(span, err)
+
+	return brand, err
+}
+
+func (tr *tracedRepository) GetBrand(ctx context.Context, name string) (Brand, error) {
+	ctx, span := tr.start(ctx, "GetBrand")
+	brand, err := tr.Repository.GetBrand(ctx, name)
+	endSpan(span, err)
+
+	return brand, err
+}
+
+func (tr *tracedRepository) GetBrandByID(ctx context.Context, id int) (Brand, error) {
+	ctx, span := tr.start(ctx, "GetBrandByID", attribute.Int("brand_id", id))
+	brand, err := tr.Repository.GetBrandByID(ctx, id)
+	endSpan(span, err)
+
+	return brand, err
+}
+
+func (tr *tracedRepository) RenameBrand(ctx context.Context, id int, name string) (Brand, error) {
+	ctx, span := tr.start(ctx, "RenameBrand", attribute.Int("brand_id", id))
+	brand, err := tr.Repository.RenameBrand(ctx, id, name)
+	endSpan(span, err)
+
+	return brand, err
+}
+
+func (tr *tracedRepository) DeleteBrand(ctx context.Context, id int) error {
+	ctx, span := tr.start(ctx, "DeleteBrand", attribute.Int("brand_id", id))
+	err := tr.Repository.DeleteBrand(ctx, id)
+	endSpan(span, err)
+
+	return err
+}
+
+func (tr *tracedRepository) AddPromotion(ctx context.Context, promotion Promotion) (Promotion, error) {
+	ctx, span := tr.start(ctx, "AddPromotion", attribute.Int("brand_id", promotion.BrandID), attribute.Int("products", len(promotion.ProductIDs)))
+	promotion, err := tr.Repository.AddPromotion(ctx, promotion)
+	endSpan(span, err)
+
+	return promotion, err
+}
+
+func (tr *tracedRepository) GetPromotionByID(ctx context.Context, id int) (Promotion, error) {
+	ctx, span := tr.start(ctx, "GetPromotionByID", attribute.Int("promotion_id", id))
+	promotion, err := tr.Repository.GetPromotionByID(ctx, id)
+	endSpan(span, err)
+
+	return promotion, err
+}
+
+func (tr *tracedRepository) GetPromotions(ctx context.Context, brandID int, productIDs []int, from, to time.Time) ([]Promotion, error) {
+	ctx, span := tr.start(ctx, "GetPromotions", attribute.Int("brand_id", brandID), attribute.Int("products", len(productIDs)))
+	promotions, err := tr.Repository.GetPromotions(ctx, brandID, productIDs, from, to)
+	endSpan(span, err)
+
+	return promotions, err
+}
+
+func (tr *tracedRepository) DeletePromotion(ctx context.Context, id int) error {
+	ctx, span := tr.start(ctx, "DeletePromotion", attribute.Int("promotion_id", id))
+	err := tr.Repository.DeletePromotion(ctx, id)
+	endSpan(span, err)
+
+	return err
+}
+
+// querySpans creates a span for every SQL query and copy as a pgx.QueryTracer
+// and pgx.CopyFromTracer. Spans are named after the calling Repository method
+// when traced, otherwise the SQL operation.
+type querySpans struct {
+	tracer trace.Tracer
+}
+
+func (qs querySpans) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) context.Context {
+	name := operation
+	if method, ok := ctx.Value(repositoryOperationKey{}).(string); ok {
+		name = method
+	}
+
+	attrs = append(attrs, semconv.DBSystemPostgreSQL, semconv.DBOperationName(operation))
+	ctx, _ = qs.tracer.Start(ctx, "postgres "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx
+}
+
+func (qs querySpans) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return qs.start(ctx, sqlOperation(data.SQL), semconv.DBQueryText(data.SQL))
+}
+
+func (qs querySpans) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	err := data.Err
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil // returned as ErrNotFound by the repository
+	}
+
+	endSpan(trace.SpanFromContext(ctx), err)
+}
+
+func (qs querySpans) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return qs.start(ctx, "COPY", semconv.DBCollectionName(data.TableName.Sanitize()))
+}
+
+func (qs querySpans) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+// sqlStatements are the keywords a WITH query's main statement starts with.
+var sqlStatements = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "MERGE"}
+
+// sqlOperation returns the statement keyword of sql, e.g: SELECT, skipping any
+// leading WITH common table expressions.
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+	if operation != "WITH" {
+		return operation
+	}
+
+	// the statement is the first keyword outside of the CTE parentheses.
+	depth := 0
+	for _, field := range strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(sql)) {
+		switch field {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		default:
+			if keyword := strings.ToUpper(field); depth == 0 && slices.Contains(sqlStatements, keyword) {
+				return keyword
+			}
+		}
+	}
+
+	return operation
+}
+
+// queryTracers calls each pgx.QueryTracer in order, pgx only accepts one.
+// Tracers that are also a pgx.CopyFromTracer are called for copies.
+type queryTracers []pgx.QueryTracer
+
+func (qt queryTracers) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	for _, tracer := range qt {
+		ctx = tracer.TraceQueryStart(ctx, conn, data)
+	}
+
+	return ctx
+}
+
+func (qt queryTracers) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	for _, tracer := range qt {
+		tracer.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+func (qt queryTracers) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	for _, tracer := range qt {
+		if tracer, ok := tracer.(pgx.CopyFromTracer); ok {
+			ctx = tracer.TraceCopyFromStart(ctx, conn, data)
+		}
+	}
+
+	return ctx
+}
+
+func (qt queryTracers) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	for _, tracer := range qt {
+		if tracer, ok := tracer.(pgx.CopyFromTracer); ok {
+			tracer.TraceCopyFromEnd(ctx, conn, data)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package pricing_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karlskewes/pricing"
+)
+
+func TestTracingConfig_NewTracerProvider(t *testing.T) {
+	testCases := map[string]struct {
+		cfg      pricing.TracingConfig
+		wantNil  bool
+		wantSpan bool
+		wantErr  bool
+	}{
+		"none":    {cfg: pricing.TracingConfig{Exporter: "none"}, wantNil: true},
+		"stdout":  {cfg: pricing.TracingConfig{Exporter: "stdout"}, wantSpan: true},
+		"otlp":    {cfg: pricing.TracingConfig{Exporter: "otlp", Endpoint: "http://localhost:4318"}},
+		"invalid": {cfg: pricing.TracingConfig{Exporter: "zipkin"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		tt := tc
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var buf bytes.Buffer
+			tp, err := tt.cfg.NewTracerProvider(ctx, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTracerProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (tp == nil) != tt.wantNil {
+				t.Fatalf("want nil tracer provider: %t - got: %v", tt.wantNil, tp)
+			}
+			if tp == nil || !tt.wantSpan {
+				return
+			}
+
+			_, span := tp.Tracer("test").Start(ctx, "test span")
+			span.End()
+
+			if err := tp.Shutdown(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), `"Name":"test span"`) {
+				t.Errorf("stdout exporter missing span:\n%s", buf.String())
+			}
+		})
+	}
+}